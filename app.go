@@ -2,7 +2,6 @@ package arpio
 
 import (
 	"encoding/json"
-	"fmt"
 	"time"
 )
 
@@ -23,7 +22,7 @@ type App struct {
 	SelectionRules     []SelectionRule `json:"-"`
 	SourceAwsAccountID string          `json:"sourceAwsAccountId"`
 	SourceRegion       string          `json:"sourceRegion"`
-	SyncPhase          string          `json:"syncPhase,omitempty"`
+	SyncPhase          SyncPhase       `json:"syncPhase,omitempty"`
 	TargetAwsAccountID string          `json:"targetAwsAccountId"`
 	TargetRegion       string          `json:"targetRegion"`
 
@@ -36,15 +35,10 @@ func (a App) MarshalJSON() ([]byte, error) {
 	type AppDTO App
 
 	// Marshal SelectionRules through RawSelectionRules
-	a.RawSelectionRules = []json.RawMessage{}
-	if a.SelectionRules != nil {
-		for _, r := range a.SelectionRules {
-			b, err := json.Marshal(r)
-			if err != nil {
-				return nil, err
-			}
-			a.RawSelectionRules = append(a.RawSelectionRules, b)
-		}
+	var err error
+	a.RawSelectionRules, err = marshalSelectionRules(a.SelectionRules)
+	if err != nil {
+		return nil, err
 	}
 
 	return json.Marshal((AppDTO)(a))
@@ -59,34 +53,65 @@ func (a *App) UnmarshalJSON(b []byte) error {
 	}
 
 	// Unmarshal RawSelectionRules into SelectionRules
-	a.SelectionRules = []SelectionRule{}
-	for _, rawSelectionRule := range a.RawSelectionRules {
-		var baseRule selectionRule
-		err = json.Unmarshal(rawSelectionRule, &baseRule)
+	a.SelectionRules, err = unmarshalSelectionRules(a.RawSelectionRules)
+	return err
+}
+
+// marshalSelectionRules marshals each of the polymorphic rules.
+func marshalSelectionRules(rules []SelectionRule) ([]json.RawMessage, error) {
+	raw := []json.RawMessage{}
+	for _, r := range rules {
+		b, err := json.Marshal(r)
 		if err != nil {
-			return err
+			return nil, err
 		}
+		raw = append(raw, b)
+	}
+	return raw, nil
+}
 
-		var rule SelectionRule
-		switch baseRule.RuleType {
-		case ArnRuleType:
-			r := &ArnRule{}
-			err = json.Unmarshal(rawSelectionRule, r)
-			rule = *r
-		case TagRuleType:
-			r := &TagRule{}
-			err = json.Unmarshal(rawSelectionRule, r)
-			rule = *r
-		default:
-			panic(fmt.Sprintf("unhandled selection rule type: %s", baseRule.RuleType))
-		}
+// unmarshalSelectionRules decodes each of the raw rules according to its
+// ruleType.
+func unmarshalSelectionRules(raw []json.RawMessage) ([]SelectionRule, error) {
+	rules := []SelectionRule{}
+	for _, rawSelectionRule := range raw {
+		rule, err := unmarshalSelectionRule(rawSelectionRule)
 		if err != nil {
-			return err
+			return nil, err
 		}
-		a.SelectionRules = append(a.SelectionRules, rule)
+		rules = append(rules, rule)
 	}
+	return rules, nil
+}
 
-	return nil
+// unmarshalSelectionRule decodes a rule with the decoder registered for its
+// ruleType, or as an UnknownRule if there is none.
+func unmarshalSelectionRule(raw json.RawMessage) (SelectionRule, error) {
+	var baseRule selectionRule
+	err := json.Unmarshal(raw, &baseRule)
+	if err != nil {
+		return nil, err
+	}
+
+	if decode, ok := lookupSelectionRuleDecoder(baseRule.RuleType); ok {
+		return decode(raw)
+	}
+	return UnknownRule{
+		selectionRule: baseRule,
+		Raw:           append(json.RawMessage(nil), raw...),
+	}, nil
+}
+
+// UnknownSelectionRuleTypes returns the types of the App's selection rules
+// that this client doesn't recognize (see UnknownRule), without duplicates.
+// A non-empty result means the client is older than the Arpio service.
+func (a App) UnknownSelectionRuleTypes() (types []string) {
+	walkSelectionRules(a.SelectionRules, func(r SelectionRule) {
+		if u, ok := r.(UnknownRule); ok && !SliceContainsString(u.RuleType, types) {
+			types = append(types, u.RuleType)
+		}
+	})
+	return types
 }
 
 // SyncPair returns a SyncPair struct for the App's endpoint information.
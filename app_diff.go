@@ -0,0 +1,219 @@
+package arpio
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// FieldChange describes how one field of an App differs between two
+// versions of it.
+type FieldChange struct {
+	// Field is the name of the App field.
+	Field string
+
+	// Old and New are the formatted values of scalar fields.
+	Old string
+	New string
+
+	// Added and Removed are the formatted elements added to and removed from
+	// set-like fields (NotificationEmails and SelectionRules), sorted.
+	Added   []string
+	Removed []string
+
+	// RequiresReplacement is true for fields UpdateApp can't change, so the
+	// app must be deleted and created again.
+	RequiresReplacement bool
+}
+
+// AppDiff is the difference between two versions of an App, as computed by
+// DiffApps.
+type AppDiff struct {
+	// Name is the name of the desired app.
+	Name    string
+	Changes []FieldChange
+}
+
+// DiffApps compares the fields of current that can be set by the caller with
+// those of desired.  Server-managed fields (AccountID, AppID, CreatedAt and
+// SyncPhase) are ignored.  SelectionRules are compared after normalizing
+// them (see NormalizeSelectionRules), and NotificationEmails regardless of
+// order.  Changes to the sync pair or type require replacing the app.
+func DiffApps(current, desired App) AppDiff {
+	d := AppDiff{Name: desired.Name}
+	scalar := func(field, old, new string, replace bool) {
+		if old != new {
+			d.Changes = append(d.Changes, FieldChange{
+				Field:               field,
+				Old:                 old,
+				New:                 new,
+				RequiresReplacement: replace,
+			})
+		}
+	}
+	set := func(field string, old, new []string) {
+		added, removed := diffStringSets(old, new)
+		if len(added) > 0 || len(removed) > 0 {
+			d.Changes = append(d.Changes, FieldChange{
+				Field:   field,
+				Added:   added,
+				Removed: removed,
+			})
+		}
+	}
+
+	scalar("Name", strconv.Quote(current.Name), strconv.Quote(desired.Name), false)
+	scalar("AppType", strconv.Quote(current.AppType), strconv.Quote(desired.AppType), true)
+	scalar("RPO", strconv.Itoa(current.RPO), strconv.Itoa(desired.RPO), false)
+	scalar("SourceAwsAccountID", strconv.Quote(current.SourceAwsAccountID), strconv.Quote(desired.SourceAwsAccountID), true)
+	scalar("SourceRegion", strconv.Quote(current.SourceRegion), strconv.Quote(desired.SourceRegion), true)
+	scalar("TargetAwsAccountID", strconv.Quote(current.TargetAwsAccountID), strconv.Quote(desired.TargetAwsAccountID), true)
+	scalar("TargetRegion", strconv.Quote(current.TargetRegion), strconv.Quote(desired.TargetRegion), true)
+	set("NotificationEmails", quoteAll(current.NotificationEmails), quoteAll(desired.NotificationEmails))
+	set("SelectionRules", formatRuleSet(current.SelectionRules), formatRuleSet(desired.SelectionRules))
+
+	return d
+}
+
+// HasChanges reports whether the apps differ.
+func (d AppDiff) HasChanges() bool {
+	return len(d.Changes) > 0
+}
+
+// RequiresReplacement reports whether any of the changes requires replacing
+// the app.
+func (d AppDiff) RequiresReplacement() bool {
+	for _, c := range d.Changes {
+		if c.RequiresReplacement {
+			return true
+		}
+	}
+	return false
+}
+
+// String renders the diff as a plan, in the style of "terraform plan":
+//
+//	~ app "my-app"
+//	    ~ RPO: 60 -> 120
+//	    ~ SelectionRules:
+//	        + tag:env=prod
+//	        - tag:env=staging
+//
+//	Plan: 2 fields to change.
+func (d AppDiff) String() string {
+	if !d.HasChanges() {
+		return fmt.Sprintf("app %q: no changes.\n", d.Name)
+	}
+
+	var b strings.Builder
+	if d.RequiresReplacement() {
+		fmt.Fprintf(&b, "-/+ app %q (must be replaced)\n", d.Name)
+	} else {
+		fmt.Fprintf(&b, "~ app %q\n", d.Name)
+	}
+	for _, c := range d.Changes {
+		marker := "~"
+		if c.RequiresReplacement {
+			marker = "-/+"
+		}
+		if c.Added == nil && c.Removed == nil {
+			fmt.Fprintf(&b, "    %s %s: %s -> %s", marker, c.Field, c.Old, c.New)
+			if c.RequiresReplacement {
+				b.WriteString(" (forces replacement)")
+			}
+			b.WriteString("\n")
+			continue
+		}
+		fmt.Fprintf(&b, "    %s %s:\n", marker, c.Field)
+		for _, v := range c.Added {
+			fmt.Fprintf(&b, "        + %s\n", v)
+		}
+		for _, v := range c.Removed {
+			fmt.Fprintf(&b, "        - %s\n", v)
+		}
+	}
+
+	fields := "fields"
+	if len(d.Changes) == 1 {
+		fields = "field"
+	}
+	fmt.Fprintf(&b, "\nPlan: %d %s to change", len(d.Changes), fields)
+	if d.RequiresReplacement() {
+		b.WriteString(", app must be replaced")
+	}
+	b.WriteString(".\n")
+	return b.String()
+}
+
+// formatRuleSet formats each of the normalized rules, in the expression
+// language where possible and as JSON otherwise.  Normalizing merges
+// top-level ArnRules, ArnPatternRules and ResourceTypeRules, so they are
+// split back into one rule per value; otherwise changing a single ARN would
+// show every ARN as removed and added again.
+func formatRuleSet(rules []SelectionRule) []string {
+	normalized, _ := NormalizeSelectionRules(rules)
+	var formatted []string
+	for _, r := range normalized {
+		for _, r := range splitRule(r) {
+			s, err := FormatSelectionRules([]SelectionRule{r})
+			if err != nil {
+				s = ruleKey(r)
+			}
+			formatted = append(formatted, s)
+		}
+	}
+	return formatted
+}
+
+// splitRule splits a rule that lists several values into equivalent rules
+// with one value each.  Other rules are returned as they are.
+func splitRule(r SelectionRule) []SelectionRule {
+	var split []SelectionRule
+	switch t := r.(type) {
+	case ArnRule:
+		for _, v := range t.Arns {
+			split = append(split, NewArnRule([]string{v}))
+		}
+	case ArnPatternRule:
+		for _, v := range t.Patterns {
+			split = append(split, NewArnPatternRule([]string{v}))
+		}
+	case ResourceTypeRule:
+		for _, v := range t.ResourceTypes {
+			split = append(split, NewResourceTypeRule([]string{v}))
+		}
+	default:
+		return []SelectionRule{r}
+	}
+	if len(split) == 0 {
+		return []SelectionRule{r}
+	}
+	return split
+}
+
+// diffStringSets returns the sorted elements of new that aren't in old, and
+// of old that aren't in new.
+func diffStringSets(old, new []string) (added, removed []string) {
+	for _, v := range new {
+		if !SliceContainsString(v, old) && !SliceContainsString(v, added) {
+			added = append(added, v)
+		}
+	}
+	for _, v := range old {
+		if !SliceContainsString(v, new) && !SliceContainsString(v, removed) {
+			removed = append(removed, v)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed
+}
+
+func quoteAll(s []string) []string {
+	quoted := make([]string, len(s))
+	for i, v := range s {
+		quoted[i] = strconv.Quote(v)
+	}
+	return quoted
+}
@@ -0,0 +1,111 @@
+package arpio
+
+import (
+	"testing"
+)
+
+func TestDiffAppsNoChanges(t *testing.T) {
+	current := validApp()
+	current.AppID = "app-1"
+	current.SyncPhase = "syncing"
+	current.NotificationEmails = []string{"a@example.com", "b@example.com"}
+	current.SelectionRules = []SelectionRule{
+		NewTagRule("env", "prod"),
+		NewArnRule([]string{"arn:b", "arn:a"}),
+	}
+
+	// Order and server-managed fields don't matter
+	desired := validApp()
+	desired.NotificationEmails = []string{"b@example.com", "a@example.com"}
+	desired.SelectionRules = []SelectionRule{
+		NewArnRule([]string{"arn:a"}),
+		NewTagRule("env", "prod"),
+		NewArnRule([]string{"arn:b"}),
+	}
+
+	d := DiffApps(current, desired)
+	if d.HasChanges() {
+		t.Fatalf("unexpected changes: %s", d)
+	}
+	expected := "app \"my-app\": no changes.\n"
+	if d.String() != expected {
+		t.Fatalf("%s != %s", d.String(), expected)
+	}
+}
+
+func TestDiffAppsUpdate(t *testing.T) {
+	current := validApp()
+	current.SelectionRules = []SelectionRule{NewTagRule("env", "prod"), NewTagRule("env", "staging")}
+
+	desired := validApp()
+	desired.RPO = 120
+	desired.NotificationEmails = []string{"ops@example.com", "oncall@example.com"}
+	desired.SelectionRules = []SelectionRule{NewTagRule("env", "prod"), NewTagExistsRule("backup")}
+
+	d := DiffApps(current, desired)
+	if d.RequiresReplacement() {
+		t.Fatal("update should not require replacement")
+	}
+	expected := `~ app "my-app"
+    ~ RPO: 60 -> 120
+    ~ NotificationEmails:
+        + "oncall@example.com"
+    ~ SelectionRules:
+        + tag:backup
+        - tag:env=staging
+
+Plan: 3 fields to change.
+`
+	if d.String() != expected {
+		t.Fatalf("%s != %s", d.String(), expected)
+	}
+}
+
+func TestDiffAppsReplacement(t *testing.T) {
+	current := validApp()
+	desired := validApp()
+	desired.TargetRegion = "us-east-2"
+	desired.Name = "renamed"
+
+	d := DiffApps(current, desired)
+	if !d.RequiresReplacement() {
+		t.Fatal("region change should require replacement")
+	}
+	expected := `-/+ app "renamed" (must be replaced)
+    ~ Name: "my-app" -> "renamed"
+    -/+ TargetRegion: "us-west-2" -> "us-east-2" (forces replacement)
+
+Plan: 2 fields to change, app must be replaced.
+`
+	if d.String() != expected {
+		t.Fatalf("%s != %s", d.String(), expected)
+	}
+}
+
+func TestDiffAppsSingleArn(t *testing.T) {
+	current := validApp()
+	current.SelectionRules = []SelectionRule{
+		NewArnRule([]string{"arn:aws:s3:::a", "arn:aws:s3:::b"}),
+		NewArnRule([]string{"arn:aws:s3:::c"}),
+		NewResourceTypeRule([]string{"AWS::RDS::DBCluster"}),
+	}
+
+	desired := validApp()
+	desired.SelectionRules = []SelectionRule{
+		NewArnRule([]string{"arn:aws:s3:::a", "arn:aws:s3:::d", "arn:aws:s3:::c"}),
+		NewResourceTypeRule([]string{"AWS::RDS::DBCluster", "AWS::EC2::Instance"}),
+	}
+
+	d := DiffApps(current, desired)
+	expected := `~ app "my-app"
+    ~ SelectionRules:
+        + arn:arn:aws:s3:::d
+        + type:AWS::EC2::Instance
+        - arn:arn:aws:s3:::b
+
+Plan: 1 field to change.
+`
+	if d.String() != expected {
+		t.Fatalf("%s != %s", d.String(), expected)
+	}
+}
@@ -0,0 +1,303 @@
+package arpio
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/yaml"
+)
+
+// AppDocumentVersion is the version of the AppDocument format this client
+// writes, and the newest version it reads.
+const AppDocumentVersion = 1
+
+// serverManagedAppFields are the JSON fields of an App that the Arpio
+// service sets, which are left out of an AppDocument.
+var serverManagedAppFields = []string{"accountId", "appId", "createdAt", "syncPhase"}
+
+// AppDocument is a portable, versioned document of app definitions, for
+// backing apps up and copying them between Arpio accounts.  It is encoded as
+// JSON or YAML, without the server-managed App fields (AccountID, AppID,
+// CreatedAt and SyncPhase).
+type AppDocument struct {
+	Version int   `json:"version"`
+	Apps    []App `json:"apps"`
+}
+
+// NewAppDocument creates an AppDocument of apps, without their
+// server-managed fields.
+func NewAppDocument(apps []App) *AppDocument {
+	d := &AppDocument{Version: AppDocumentVersion, Apps: []App{}}
+	for _, a := range apps {
+		d.Apps = append(d.Apps, withoutServerFields(a))
+	}
+	return d
+}
+
+// withoutServerFields returns a with its server-managed fields cleared.
+func withoutServerFields(a App) App {
+	a.AccountID = ""
+	a.AppID = ""
+	a.CreatedAt = time.Time{}
+	a.SyncPhase = ""
+	return a
+}
+
+func (d AppDocument) MarshalJSON() ([]byte, error) {
+	raw := struct {
+		Version int                          `json:"version"`
+		Apps    []map[string]json.RawMessage `json:"apps"`
+	}{Version: d.Version, Apps: []map[string]json.RawMessage{}}
+
+	// Marshal each App, then drop its server-managed fields
+	for _, a := range d.Apps {
+		b, err := json.Marshal(a)
+		if err != nil {
+			return nil, err
+		}
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(b, &fields); err != nil {
+			return nil, err
+		}
+		for _, f := range serverManagedAppFields {
+			delete(fields, f)
+		}
+		raw.Apps = append(raw.Apps, fields)
+	}
+
+	return json.Marshal(raw)
+}
+
+func (d *AppDocument) UnmarshalJSON(b []byte) error {
+	// Use a type alias to avoid invoking this function recursively
+	type AppDocumentDTO AppDocument
+	err := json.Unmarshal(b, (*AppDocumentDTO)(d))
+	if err != nil {
+		return err
+	}
+
+	if d.Version < 1 || d.Version > AppDocumentVersion {
+		return fmt.Errorf("unsupported app document version %d; this client "+
+			"supports versions 1 to %d", d.Version, AppDocumentVersion)
+	}
+	if d.Apps == nil {
+		d.Apps = []App{}
+	}
+	return nil
+}
+
+// YAML encodes the document as YAML.
+func (d AppDocument) YAML() ([]byte, error) {
+	b, err := json.Marshal(d)
+	if err != nil {
+		return nil, err
+	}
+	return yaml.JSONToYAML(b)
+}
+
+// ParseAppDocument parses a YAML or JSON AppDocument.
+func ParseAppDocument(b []byte) (*AppDocument, error) {
+	j, err := yaml.YAMLToJSON(b)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.Equal(bytes.TrimSpace(j), []byte("null")) {
+		return nil, fmt.Errorf("empty app document")
+	}
+
+	var d AppDocument
+	err = json.Unmarshal(j, &d)
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// ExportApps exports all the applications in the account the Client is
+// configured to use, sorted by name.
+func (c *Client) ExportApps() (*AppDocument, error) {
+	return c.ExportAppsWithContext(context.Background())
+}
+
+// ExportAppsWithContext is like ExportApps, but the request is bound to ctx.
+func (c *Client) ExportAppsWithContext(ctx context.Context) (*AppDocument, error) {
+	apps, err := c.ListAppsWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(apps, func(i, j int) bool {
+		return apps[i].Name < apps[j].Name
+	})
+	return NewAppDocument(apps), nil
+}
+
+// ImportOptions control how ImportApps creates the apps of an AppDocument.
+type ImportOptions struct {
+	// AccountIDs maps AWS account IDs in the document to the account IDs to
+	// use instead, in the sync pairs and in the ARNs of selection rules.
+	AccountIDs map[string]string
+
+	// Regions maps AWS regions in the document to the regions to use
+	// instead, like AccountIDs.
+	Regions map[string]string
+
+	// SkipExisting skips apps named like an app that already exists, rather
+	// than failing the import.
+	SkipExisting bool
+}
+
+// NameCollisionError is returned by ImportApps when apps in the document
+// are named like existing apps, or like each other.
+type NameCollisionError struct {
+	Names []string
+}
+
+func (e *NameCollisionError) Error() string {
+	return fmt.Sprintf("apps named %s already exist or are repeated in the document", strings.Join(quoteAll(e.Names), ", "))
+}
+
+// ImportResult describes the apps ImportApps created and skipped.
+type ImportResult struct {
+	Created []App
+	Skipped []string
+}
+
+// ImportApps creates the apps of an AppDocument in the account the Client is
+// configured to use, after rewriting them according to opts.  Server-managed
+// fields in the document, such as AppID, are ignored.
+//
+// Nothing is created if any of the rewritten apps is invalid (see
+// App.Validate), or, unless opts.SkipExisting is set, if any of them is
+// named like an existing app (see NameCollisionError).  Apps are created in
+// order, and the import stops at the first one that fails.
+func (c *Client) ImportApps(d AppDocument, opts ImportOptions) (*ImportResult, error) {
+	return c.ImportAppsWithContext(context.Background(), d, opts)
+}
+
+// ImportAppsWithContext is like ImportApps, but requests are bound to ctx.
+func (c *Client) ImportAppsWithContext(ctx context.Context, d AppDocument, opts ImportOptions) (*ImportResult, error) {
+	existing, err := c.ListAppsWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	names := map[string]bool{}
+	for _, a := range existing {
+		names[a.Name] = true
+	}
+
+	result := &ImportResult{Created: []App{}}
+	var pending []App
+	var collisions []string
+	seen := map[string]bool{}
+	for _, a := range d.Apps {
+		a = withoutServerFields(opts.rewrite(a))
+		a.AccountID = c.AccountID
+		if err := a.Validate(); err != nil {
+			return nil, fmt.Errorf("app %q: %w", a.Name, err)
+		}
+
+		switch {
+		case seen[a.Name]:
+			collisions = append(collisions, a.Name)
+		case names[a.Name] && opts.SkipExisting:
+			result.Skipped = append(result.Skipped, a.Name)
+		case names[a.Name]:
+			collisions = append(collisions, a.Name)
+		default:
+			pending = append(pending, a)
+		}
+		seen[a.Name] = true
+	}
+	if len(collisions) > 0 {
+		return nil, &NameCollisionError{Names: collisions}
+	}
+
+	for _, a := range pending {
+		created, err := c.CreateAppWithContext(ctx, a)
+		if err != nil {
+			return result, fmt.Errorf("creating app %q: %w", a.Name, err)
+		}
+		result.Created = append(result.Created, created)
+	}
+	return result, nil
+}
+
+// rewrite maps the account IDs and regions of an app.
+func (opts ImportOptions) rewrite(a App) App {
+	a.SourceAwsAccountID = mapString(opts.AccountIDs, a.SourceAwsAccountID)
+	a.SourceRegion = mapString(opts.Regions, a.SourceRegion)
+	a.TargetAwsAccountID = mapString(opts.AccountIDs, a.TargetAwsAccountID)
+	a.TargetRegion = mapString(opts.Regions, a.TargetRegion)
+
+	rules := make([]SelectionRule, len(a.SelectionRules))
+	for i, r := range a.SelectionRules {
+		rules[i] = opts.rewriteRule(r)
+	}
+	a.SelectionRules = rules
+	return a
+}
+
+// rewriteRule maps the account IDs and regions of the ARNs in a rule,
+// recursively.
+func (opts ImportOptions) rewriteRule(r SelectionRule) SelectionRule {
+	rewriteAll := func(arns []string) []string {
+		rewritten := make([]string, len(arns))
+		for i, s := range arns {
+			rewritten[i] = opts.rewriteARN(s)
+		}
+		return rewritten
+	}
+	rewriteRules := func(rules []SelectionRule) []SelectionRule {
+		rewritten := make([]SelectionRule, len(rules))
+		for i, sub := range rules {
+			rewritten[i] = opts.rewriteRule(sub)
+		}
+		return rewritten
+	}
+
+	switch t := r.(type) {
+	case ArnRule:
+		t.Arns = rewriteAll(t.Arns)
+		return t
+	case ArnPatternRule:
+		t.Patterns = rewriteAll(t.Patterns)
+		return t
+	case AllOfRule:
+		t.Rules = rewriteRules(t.Rules)
+		return t
+	case AnyOfRule:
+		t.Rules = rewriteRules(t.Rules)
+		return t
+	case NotRule:
+		if t.Rule != nil {
+			t.Rule = opts.rewriteRule(t.Rule)
+		}
+		return t
+	default:
+		return r
+	}
+}
+
+// rewriteARN maps the region and account ID fields of an ARN or ARN
+// pattern.
+func (opts ImportOptions) rewriteARN(s string) string {
+	parts := strings.SplitN(s, ":", 6)
+	if len(parts) != 6 {
+		return s
+	}
+	parts[3] = mapString(opts.Regions, parts[3])
+	parts[4] = mapString(opts.AccountIDs, parts[4])
+	return strings.Join(parts, ":")
+}
+
+func mapString(m map[string]string, s string) string {
+	if v, ok := m[s]; ok {
+		return v
+	}
+	return s
+}
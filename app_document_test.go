@@ -0,0 +1,203 @@
+package arpio_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	arpio "github.com/arpio/arpio-client-go"
+	"github.com/arpio/arpio-client-go/arpiotest"
+)
+
+func TestAppDocumentEncoding(t *testing.T) {
+	a := desiredApp("web", 60)
+	a.AccountID = "acct"
+	a.AppID = "app-1"
+	a.CreatedAt = time.Date(2021, 9, 3, 0, 0, 0, 0, time.UTC)
+	a.SyncPhase = "syncing"
+	d := arpio.NewAppDocument([]arpio.App{a})
+
+	expected := `apps:
+- name: web
+  rpo: 60
+  selectionRules:
+  - name: app
+    ruleType: tag
+    value: web
+  sourceAwsAccountId: "111111111111"
+  sourceRegion: us-east-1
+  targetAwsAccountId: "222222222222"
+  targetRegion: us-west-2
+  type: standard
+version: 1
+`
+	b, err := d.YAML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != expected {
+		t.Fatalf("%s != %s", b, expected)
+	}
+
+	parsed, err := arpio.ParseAppDocument(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(parsed.Apps[0].SelectionRules, a.SelectionRules) {
+		t.Fatalf("%#v != %#v", parsed.Apps[0].SelectionRules, a.SelectionRules)
+	}
+	b, err = parsed.YAML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != expected {
+		t.Fatalf("%s != %s", b, expected)
+	}
+}
+
+func TestParseAppDocumentVersion(t *testing.T) {
+	for _, in := range []string{`{"apps": []}`, `{"version": 2, "apps": []}`, ``} {
+		if _, err := arpio.ParseAppDocument([]byte(in)); err == nil {
+			t.Fatalf("%q: expected an error", in)
+		}
+	}
+}
+
+func TestExportImportApps(t *testing.T) {
+	s := arpiotest.NewServer()
+	defer s.Close()
+	src, err := s.Client("src")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dst, err := s.Client("dst")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	web := desiredApp("web", 60)
+	web.AccountID = "src"
+	web.SelectionRules = []arpio.SelectionRule{
+		arpio.NewNotRule(arpio.NewArnRule([]string{"arn:aws:rds:us-east-1:111111111111:db:db1"})),
+		arpio.NewArnPatternRule([]string{"arn:aws:s3:::logs-*"}),
+	}
+	s.SeedApp(web)
+	db := desiredApp("db", 60)
+	db.AccountID = "src"
+	s.SeedApp(db)
+	existing := desiredApp("db", 60)
+	existing.AccountID = "dst"
+	s.SeedApp(existing)
+
+	d, err := src.ExportApps()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(d.Apps) != 2 || d.Apps[0].Name != "db" || d.Apps[1].Name != "web" {
+		t.Fatalf("unexpected export: %#v", d.Apps)
+	}
+
+	opts := arpio.ImportOptions{
+		AccountIDs: map[string]string{"111111111111": "333333333333"},
+		Regions:    map[string]string{"us-east-1": "eu-west-1"},
+	}
+
+	// The existing app's name collides
+	_, err = dst.ImportApps(*d, opts)
+	var collisionErr *arpio.NameCollisionError
+	if !errors.As(err, &collisionErr) {
+		t.Fatalf("expected *NameCollisionError, got %v", err)
+	}
+	if !reflect.DeepEqual(collisionErr.Names, []string{"db"}) {
+		t.Fatalf("%v != [db]", collisionErr.Names)
+	}
+	if len(s.Apps("dst")) != 1 {
+		t.Fatalf("apps were created despite the collision: %v", s.Apps("dst"))
+	}
+
+	opts.SkipExisting = true
+	result, err := dst.ImportApps(*d, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(result.Skipped, []string{"db"}) || len(result.Created) != 1 {
+		t.Fatalf("unexpected result: %#v", result)
+	}
+
+	created := result.Created[0]
+	if created.AccountID != "dst" || created.SourceAwsAccountID != "333333333333" || created.SourceRegion != "eu-west-1" {
+		t.Fatalf("app was not re-targeted: %#v", created)
+	}
+	expectedRules := []arpio.SelectionRule{
+		arpio.NewNotRule(arpio.NewArnRule([]string{"arn:aws:rds:eu-west-1:333333333333:db:db1"})),
+		arpio.NewArnPatternRule([]string{"arn:aws:s3:::logs-*"}),
+	}
+	if !reflect.DeepEqual(created.SelectionRules, expectedRules) {
+		t.Fatalf("%#v != %#v", created.SelectionRules, expectedRules)
+	}
+}
+
+func TestImportAppsRepeatedName(t *testing.T) {
+	s := arpiotest.NewServer()
+	defer s.Close()
+	c, err := s.Client("acct")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := arpio.NewAppDocument([]arpio.App{desiredApp("web", 60), desiredApp("web", 120)})
+	_, err = c.ImportApps(*d, arpio.ImportOptions{SkipExisting: true})
+	if err == nil || !strings.Contains(err.Error(), `"web"`) {
+		t.Fatalf("expected a name collision error, got %v", err)
+	}
+}
+
+func TestImportAppsClearsServerFields(t *testing.T) {
+	s := arpiotest.NewServer()
+	defer s.Close()
+
+	var sent []arpio.App
+	c, err := s.Client("acct", arpio.WithMiddleware(func(next arpio.RoundTripFunc) arpio.RoundTripFunc {
+		return func(ctx context.Context, req *arpio.APIRequest) (*arpio.APIResponse, error) {
+			if a, ok := req.Body.(arpio.App); ok {
+				sent = append(sent, a)
+			}
+			return next(ctx, req)
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := arpio.ParseAppDocument([]byte(`
+version: 1
+apps:
+- name: web
+  appId: app-stale
+  createdAt: "2021-09-03T00:00:00Z"
+  syncPhase: syncing
+  rpo: 60
+  sourceAwsAccountId: "111111111111"
+  sourceRegion: us-east-1
+  targetAwsAccountId: "222222222222"
+  targetRegion: us-west-2
+  notificationEmails: [ops@example.com]
+  type: standard
+  selectionRules: [{ruleType: tag, name: env, value: prod}]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.ImportApps(*d, arpio.ImportOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if len(sent) != 1 {
+		t.Fatalf("expected 1 app to be sent, got %d", len(sent))
+	}
+	if sent[0].AppID != "" || !sent[0].CreatedAt.IsZero() || sent[0].SyncPhase != "" {
+		t.Fatalf("server-managed fields were sent: %#v", sent[0])
+	}
+}
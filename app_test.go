@@ -51,3 +51,30 @@ func TestAppJSON(t *testing.T) {
 		t.Fatalf("%v != %v", orig, decoded)
 	}
 }
+
+func TestAppJSONUnknownRule(t *testing.T) {
+	in := `{"accountId":"a","appId":"b","type":"c","createdAt":"0001-01-01T00:00:00Z","name":"d","rpo":0,"sourceAwsAccountId":"e","sourceRegion":"f","targetAwsAccountId":"h","targetRegion":"i","selectionRules":[{"ruleType":"tag","name":"foo","value":"bar"},{"ruleType":"future","query":{"x":[1,2]}}]}`
+
+	var decoded App
+	err := json.Unmarshal([]byte(in), &decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	types := decoded.UnknownSelectionRuleTypes()
+	if !reflect.DeepEqual(types, []string{"future"}) {
+		t.Fatalf("%v != [future]", types)
+	}
+	unknown, ok := decoded.SelectionRules[1].(UnknownRule)
+	if !ok || unknown.GetRuleType() != "future" {
+		t.Fatalf("unexpected rule %#v", decoded.SelectionRules[1])
+	}
+
+	bytes, err := json.Marshal(decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(bytes) != in {
+		t.Fatalf("%s != %s", bytes, in)
+	}
+}
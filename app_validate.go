@@ -0,0 +1,177 @@
+package arpio
+
+import (
+	"fmt"
+	"net/mail"
+	"strings"
+
+	"github.com/arpio/arpio-client-go/arn"
+)
+
+// AWSRegions are the AWS region codes App.Validate accepts; it rejects
+// SourceRegion and TargetRegion values that aren't listed, even if the Arpio
+// API would accept them.  Callers may append regions launched after this
+// client was released, before any concurrent calls to Validate.
+var AWSRegions = []string{
+	"af-south-1",
+	"ap-east-1",
+	"ap-east-2",
+	"ap-northeast-1",
+	"ap-northeast-2",
+	"ap-northeast-3",
+	"ap-south-1",
+	"ap-south-2",
+	"ap-southeast-1",
+	"ap-southeast-2",
+	"ap-southeast-3",
+	"ap-southeast-4",
+	"ap-southeast-5",
+	"ap-southeast-7",
+	"ca-central-1",
+	"ca-west-1",
+	"cn-north-1",
+	"cn-northwest-1",
+	"eu-central-1",
+	"eu-central-2",
+	"eu-north-1",
+	"eu-south-1",
+	"eu-south-2",
+	"eu-west-1",
+	"eu-west-2",
+	"eu-west-3",
+	"il-central-1",
+	"me-central-1",
+	"me-south-1",
+	"mx-central-1",
+	"sa-east-1",
+	"us-east-1",
+	"us-east-2",
+	"us-gov-east-1",
+	"us-gov-west-1",
+	"us-west-1",
+	"us-west-2",
+}
+
+// FieldError describes an invalid field of a value.
+type FieldError struct {
+	// Field is the name of the invalid field, with an index for elements of
+	// slices, such as "NotificationEmails[1]".
+	Field string
+	Msg   string
+}
+
+func (e FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Msg)
+}
+
+// ValidationError is returned by App.Validate with every invalid field it
+// found.
+type ValidationError struct {
+	Errors []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, fe := range e.Errors {
+		msgs[i] = fe.Error()
+	}
+	return fmt.Sprintf("invalid app: %s", strings.Join(msgs, "; "))
+}
+
+// Validate is a client-side pre-check of the App's fields that catches common
+// mistakes before a create or update request is sent.  It doesn't implement
+// the Arpio API's validation: an App that passes may still be rejected by
+// the API.  Server-managed fields (AccountID, AppID, CreatedAt and SyncPhase)
+// are not checked.  The returned error is a *ValidationError listing every
+// invalid field.
+func (a App) Validate() error {
+	var errs []FieldError
+	add := func(field, format string, args ...interface{}) {
+		errs = append(errs, FieldError{Field: field, Msg: fmt.Sprintf(format, args...)})
+	}
+
+	if strings.TrimSpace(a.Name) == "" {
+		add("Name", "must not be empty")
+	}
+	if a.AppType != StandardAppType && a.AppType != TerraformAppType {
+		add("AppType", "must be %q or %q, not %q", StandardAppType, TerraformAppType, a.AppType)
+	}
+	if a.RPO <= 0 {
+		add("RPO", "must be positive, not %d", a.RPO)
+	}
+
+	if !arn.IsAccountID(a.SourceAwsAccountID) {
+		add("SourceAwsAccountID", "must be a 12-digit AWS account ID, not %q", a.SourceAwsAccountID)
+	}
+	if !SliceContainsString(a.SourceRegion, AWSRegions) {
+		add("SourceRegion", "unknown AWS region %q", a.SourceRegion)
+	}
+	if !arn.IsAccountID(a.TargetAwsAccountID) {
+		add("TargetAwsAccountID", "must be a 12-digit AWS account ID, not %q", a.TargetAwsAccountID)
+	}
+	if !SliceContainsString(a.TargetRegion, AWSRegions) {
+		add("TargetRegion", "unknown AWS region %q", a.TargetRegion)
+	}
+	if a.SourceAwsAccountID == a.TargetAwsAccountID && a.SourceRegion == a.TargetRegion {
+		add("TargetRegion", "the target account and region must differ from the source")
+	}
+
+	for i, email := range a.NotificationEmails {
+		if !isEmailAddress(email) {
+			add(fmt.Sprintf("NotificationEmails[%d]", i), "malformed email address %q", email)
+		}
+	}
+
+	if len(a.SelectionRules) == 0 {
+		add("SelectionRules", "must have at least one rule")
+	}
+	for i, r := range a.SelectionRules {
+		field := fmt.Sprintf("SelectionRules[%d]", i)
+		if r == nil {
+			add(field, "must not be nil")
+			continue
+		}
+		walkSelectionRules([]SelectionRule{r}, func(r SelectionRule) {
+			switch t := r.(type) {
+			case ArnRule:
+				if err := t.Validate(); err != nil {
+					add(field, "%s", err)
+				}
+			case AllOfRule:
+				validateRuleSet(add, field, AllOfRuleType, t.Rules)
+			case AnyOfRule:
+				validateRuleSet(add, field, AnyOfRuleType, t.Rules)
+			case NotRule:
+				if t.Rule == nil {
+					add(field, "%s rule must have a rule", NotRuleType)
+				}
+			}
+		})
+	}
+
+	if len(errs) > 0 {
+		return &ValidationError{Errors: errs}
+	}
+	return nil
+}
+
+// validateRuleSet checks the rules of an AllOfRule or AnyOfRule, which must
+// not be empty or contain nil rules.
+func validateRuleSet(add func(field, format string, args ...interface{}), field, ruleType string, rules []SelectionRule) {
+	if len(rules) == 0 {
+		add(field, "%s rule must have at least one rule", ruleType)
+	}
+	for _, r := range rules {
+		if r == nil {
+			add(field, "%s rule must not contain a nil rule", ruleType)
+			break
+		}
+	}
+}
+
+// isEmailAddress reports whether s is a bare email address, such as
+// "ops@example.com".
+func isEmailAddress(s string) bool {
+	addr, err := mail.ParseAddress(s)
+	return err == nil && addr.Name == "" && addr.Address == s
+}
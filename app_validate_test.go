@@ -0,0 +1,116 @@
+package arpio
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func validApp() App {
+	return App{
+		AppType:            StandardAppType,
+		Name:               "my-app",
+		NotificationEmails: []string{"ops@example.com"},
+		RPO:                60,
+		SelectionRules:     []SelectionRule{NewTagRule("env", "prod")},
+		SourceAwsAccountID: "123456789012",
+		SourceRegion:       "us-east-1",
+		TargetAwsAccountID: "210987654321",
+		TargetRegion:       "us-west-2",
+	}
+}
+
+func TestAppValidate(t *testing.T) {
+	if err := validApp().Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The same account is fine with a different region
+	a := validApp()
+	a.TargetAwsAccountID = a.SourceAwsAccountID
+	if err := a.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAppValidateErrors(t *testing.T) {
+	a := App{
+		AppType:            "other",
+		NotificationEmails: []string{"ops@example.com", "Ops <ops@example.com>", "ops"},
+		RPO:                0,
+		SourceAwsAccountID: "12345678901a",
+		SourceRegion:       "us-east-9",
+		TargetAwsAccountID: "12345678901a",
+		TargetRegion:       "us-east-9",
+	}
+
+	err := a.Validate()
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected *ValidationError, got %v", err)
+	}
+
+	var fields []string
+	for _, fe := range validationErr.Errors {
+		fields = append(fields, fe.Field)
+	}
+	expected := []string{
+		"Name",
+		"AppType",
+		"RPO",
+		"SourceAwsAccountID",
+		"SourceRegion",
+		"TargetAwsAccountID",
+		"TargetRegion",
+		"TargetRegion",
+		"NotificationEmails[1]",
+		"NotificationEmails[2]",
+		"SelectionRules",
+	}
+	if !reflect.DeepEqual(fields, expected) {
+		t.Fatalf("%v != %v", fields, expected)
+	}
+}
+
+func TestAppValidateSelectionRuleArns(t *testing.T) {
+	a := validApp()
+	a.SelectionRules = []SelectionRule{
+		NewArnRule([]string{"arn:aws:s3:::bucket"}),
+		NewNotRule(NewArnRule([]string{"arn:bad"})),
+	}
+
+	err := a.Validate()
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected *ValidationError, got %v", err)
+	}
+	if len(validationErr.Errors) != 1 || validationErr.Errors[0].Field != "SelectionRules[1]" {
+		t.Fatalf("unexpected errors: %v", validationErr.Errors)
+	}
+}
+
+func TestAppValidateCompositeRules(t *testing.T) {
+	a := validApp()
+	a.SelectionRules = []SelectionRule{
+		NewArnRule([]string{"arn:aws:s3:::bucket"}),
+		NewAllOfRule(nil),
+		NewNotRule(NewAnyOfRule(nil)),
+		nil,
+		NewNotRule(nil),
+		NewAnyOfRule([]SelectionRule{NewArnRule([]string{"arn:aws:s3:::bucket"}), nil}),
+	}
+
+	err := a.Validate()
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected *ValidationError, got %v", err)
+	}
+	var fields []string
+	for _, e := range validationErr.Errors {
+		fields = append(fields, e.Field)
+	}
+	expected := []string{"SelectionRules[1]", "SelectionRules[2]", "SelectionRules[3]", "SelectionRules[4]", "SelectionRules[5]"}
+	if !reflect.DeepEqual(fields, expected) {
+		t.Fatalf("%v != %v", fields, expected)
+	}
+}
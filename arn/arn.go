@@ -0,0 +1,173 @@
+// Package arn parses and validates Amazon Resource Names, which the Arpio API
+// uses to identify AWS resources.
+package arn
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ARN is a parsed Amazon Resource Name of the form
+//
+//	arn:partition:service:region:account-id:resource
+//
+// where resource is "resource-id", "resource-type/resource-id" or
+// "resource-type:resource-id".  S3 bucket and object ARNs, which have neither
+// a region nor an account ID, have no resource type: the resource is
+// "bucket" or "bucket/key".
+type ARN struct {
+	Partition string
+	Service   string
+	Region    string
+
+	// AccountID is a 12-digit account ID, "aws" for AWS managed resources
+	// such as arn:aws:iam::aws:policy/AdministratorAccess, or empty.
+	AccountID string
+
+	// ResourceType is empty for ARNs whose resource has no type, such as
+	// S3 buckets and objects.
+	ResourceType string
+	ResourceID   string
+
+	// sep is the separator between ResourceType and ResourceID.
+	sep string
+}
+
+// Error describes an ARN that could not be parsed.
+type Error struct {
+	ARN string
+	Msg string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("invalid ARN %q: %s", e.ARN, e.Msg)
+}
+
+// Parse parses and validates s as an ARN.  The returned error is an *Error.
+func Parse(s string) (ARN, error) {
+	parts := strings.SplitN(s, ":", 6)
+	if len(parts) != 6 || parts[0] != "arn" {
+		return ARN{}, &Error{ARN: s, Msg: `expected "arn:partition:service:region:account-id:resource"`}
+	}
+
+	a := ARN{
+		Partition: parts[1],
+		Service:   parts[2],
+		Region:    parts[3],
+		AccountID: parts[4],
+	}
+	resource := parts[5]
+	if a.isS3Object() {
+		a.ResourceID = resource
+	} else if i := strings.IndexAny(resource, "/:"); i >= 0 {
+		a.ResourceType = resource[:i]
+		a.sep = resource[i : i+1]
+		a.ResourceID = resource[i+1:]
+	} else {
+		a.ResourceID = resource
+	}
+
+	if err := a.validate(); err != nil {
+		return ARN{}, &Error{ARN: s, Msg: err.Error()}
+	}
+	return a, nil
+}
+
+// Validate reports whether s is a valid ARN.  The returned error is an
+// *Error.
+func Validate(s string) error {
+	_, err := Parse(s)
+	return err
+}
+
+// isS3Object reports whether the ARN names an S3 bucket or object, whose
+// resource has no type.
+func (a ARN) isS3Object() bool {
+	return a.Service == "s3" && a.Region == "" && a.AccountID == ""
+}
+
+// validate checks the ARN's fields.  Partitions aren't checked against a list
+// of known partitions, so ARNs in new partitions are accepted; they only need
+// to look like one, such as "aws" or "aws-us-gov".
+func (a ARN) validate() error {
+	if !isPartition(a.Partition) {
+		return fmt.Errorf("malformed partition %q", a.Partition)
+	}
+	if a.Service == "" {
+		return fmt.Errorf("missing service")
+	}
+	if a.Region != "" && !isRegion(a.Region) {
+		return fmt.Errorf("malformed region %q", a.Region)
+	}
+	if a.AccountID != "" && a.AccountID != "aws" && !IsAccountID(a.AccountID) {
+		return fmt.Errorf("malformed account ID %q", a.AccountID)
+	}
+	if a.ResourceType == "" && a.ResourceID == "" {
+		return fmt.Errorf("missing resource")
+	}
+	return nil
+}
+
+// String returns the ARN in its standard form.
+func (a ARN) String() string {
+	resource := a.ResourceID
+	if a.ResourceType != "" {
+		sep := a.sep
+		if sep == "" {
+			sep = "/"
+		}
+		resource = a.ResourceType + sep + a.ResourceID
+	}
+	return strings.Join([]string{"arn", a.Partition, a.Service, a.Region, a.AccountID, resource}, ":")
+}
+
+// InAccount reports whether the ARN belongs to the AWS account accountID.
+func (a ARN) InAccount(accountID string) bool {
+	return a.AccountID == accountID
+}
+
+// InRegion reports whether the ARN belongs to region.  Global resources,
+// such as IAM roles and S3 buckets, are not in any region.
+func (a ARN) InRegion(region string) bool {
+	return a.Region == region
+}
+
+// IsAccountID reports whether s is a 12-digit AWS account ID.
+func IsAccountID(s string) bool {
+	if len(s) != 12 {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// isRegion reports whether s has the shape of a region code, such as
+// "us-east-1" or "us-gov-west-1".
+func isRegion(s string) bool {
+	parts := strings.Split(s, "-")
+	if len(parts) < 3 {
+		return false
+	}
+	for _, p := range parts[:len(parts)-1] {
+		if p == "" || strings.Trim(p, "abcdefghijklmnopqrstuvwxyz") != "" {
+			return false
+		}
+	}
+	last := parts[len(parts)-1]
+	return last != "" && strings.Trim(last, "0123456789") == ""
+}
+
+// isPartition reports whether s has the shape of a partition, such as "aws"
+// or "aws-us-gov".
+func isPartition(s string) bool {
+	for _, p := range strings.Split(s, "-") {
+		if p == "" || strings.Trim(p, "abcdefghijklmnopqrstuvwxyz") != "" {
+			return false
+		}
+	}
+	return true
+}
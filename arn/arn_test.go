@@ -0,0 +1,96 @@
+package arn
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		s        string
+		expected ARN
+	}{
+		{
+			"arn:aws:rds:us-east-1:123456789012:cluster:my-cluster",
+			ARN{Partition: "aws", Service: "rds", Region: "us-east-1", AccountID: "123456789012", ResourceType: "cluster", ResourceID: "my-cluster", sep: ":"},
+		},
+		{
+			"arn:aws:ec2:us-west-2:123456789012:instance/i-0123456789abcdef0",
+			ARN{Partition: "aws", Service: "ec2", Region: "us-west-2", AccountID: "123456789012", ResourceType: "instance", ResourceID: "i-0123456789abcdef0", sep: "/"},
+		},
+		{
+			"arn:aws:s3:::my-bucket",
+			ARN{Partition: "aws", Service: "s3", ResourceID: "my-bucket"},
+		},
+		{
+			"arn:aws:s3:::my-bucket/path/to/object",
+			ARN{Partition: "aws", Service: "s3", ResourceID: "my-bucket/path/to/object"},
+		},
+		{
+			"arn:aws:s3:us-west-2:123456789012:accesspoint/my-ap",
+			ARN{Partition: "aws", Service: "s3", Region: "us-west-2", AccountID: "123456789012", ResourceType: "accesspoint", ResourceID: "my-ap", sep: "/"},
+		},
+		{
+			"arn:aws:iam::aws:policy/AdministratorAccess",
+			ARN{Partition: "aws", Service: "iam", AccountID: "aws", ResourceType: "policy", ResourceID: "AdministratorAccess", sep: "/"},
+		},
+		{
+			"arn:aws-eusc:s3:::my-bucket",
+			ARN{Partition: "aws-eusc", Service: "s3", ResourceID: "my-bucket"},
+		},
+		{
+			"arn:aws-us-gov:iam::123456789012:role/path/to/role",
+			ARN{Partition: "aws-us-gov", Service: "iam", AccountID: "123456789012", ResourceType: "role", ResourceID: "path/to/role", sep: "/"},
+		},
+	}
+	for _, c := range cases {
+		a, err := Parse(c.s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if a != c.expected {
+			t.Fatalf("%#v != %#v", a, c.expected)
+		}
+		if a.String() != c.s {
+			t.Fatalf("%s != %s", a.String(), c.s)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	cases := []string{
+		"",
+		"not-an-arn",
+		"arn:aws:s3::my-bucket",
+		"arn::s3:::my-bucket",
+		"arn:AWS:s3:::my-bucket",
+		"arn:aws:iam::amazon:policy/p",
+		"arn:aws::us-east-1:123456789012:instance/i-1",
+		"arn:aws:ec2:us-east:123456789012:instance/i-1",
+		"arn:aws:ec2:us-east-1:12345:instance/i-1",
+		"arn:aws:ec2:us-east-1:123456789012:",
+	}
+	for _, s := range cases {
+		_, err := Parse(s)
+		var arnErr *Error
+		if !errors.As(err, &arnErr) {
+			t.Fatalf("%q: expected *Error, got %v", s, err)
+		}
+		if arnErr.ARN != s {
+			t.Fatalf("%s != %s", arnErr.ARN, s)
+		}
+	}
+}
+
+func TestInAccountInRegion(t *testing.T) {
+	a, err := Parse("arn:aws:rds:us-east-1:123456789012:db:my-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !a.InAccount("123456789012") || a.InAccount("210987654321") {
+		t.Fatalf("InAccount is wrong for %s", a)
+	}
+	if !a.InRegion("us-east-1") || a.InRegion("us-west-2") {
+		t.Fatalf("InRegion is wrong for %s", a)
+	}
+}
@@ -0,0 +1,235 @@
+package arpiotest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+
+	arpio "github.com/arpio/arpio-client-go"
+)
+
+// Cassette holds recorded HTTP interactions with the Arpio API.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is one recorded request and its response.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is the scrubbed form of a recorded request.
+type RecordedRequest struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// RecordedResponse is the scrubbed form of a recorded response.
+type RecordedResponse struct {
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+}
+
+// LoadCassette reads a cassette file written by Cassette.Save.
+func LoadCassette(filename string) (*Cassette, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var c Cassette
+	err = json.Unmarshal(b, &c)
+	if err != nil {
+		return nil, fmt.Errorf("error reading cassette %s: %w", filename, err)
+	}
+	return &c, nil
+}
+
+// Save writes the cassette to a file as indented JSON.
+func (c *Cassette) Save(filename string) error {
+	b, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, append(b, '\n'), 0644)
+}
+
+// requestKey identifies a request for replay matching.  The host is not
+// part of the key, so cassettes can be replayed against any API URL with
+// the same path.
+func requestKey(method, url, body string) string {
+	return method + " " + url + "\n" + body
+}
+
+// RecordingTransport is an http.RoundTripper that passes requests to
+// another transport and records them, scrubbed, in a Cassette.  Use it
+// with arpio.WithTransport.
+type RecordingTransport struct {
+	// Transport sends the requests.  If nil, http.DefaultTransport is used.
+	Transport http.RoundTripper
+
+	// Redactor scrubs recorded headers and bodies.  If nil,
+	// arpio.DefaultRedactor is used, which removes the X-Api-Key header.
+	Redactor *arpio.Redactor
+
+	mu       sync.Mutex
+	cassette Cassette
+}
+
+// NewRecordingTransport creates a RecordingTransport that sends requests
+// through transport (which may be nil).
+func NewRecordingTransport(transport http.RoundTripper) *RecordingTransport {
+	return &RecordingTransport{Transport: transport}
+}
+
+// RoundTrip sends the request and records the interaction.
+func (t *RecordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil {
+		var err error
+		reqBody, err = ioutil.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(reqBody))
+	}
+
+	transport := t.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(respBody))
+
+	interaction := Interaction{
+		Request: RecordedRequest{
+			Method: req.Method,
+			URL:    req.URL.RequestURI(),
+			Header: t.Redactor.RedactHeader(req.Header),
+			Body:   string(t.Redactor.RedactJSON(reqBody)),
+		},
+		Response: RecordedResponse{
+			StatusCode: resp.StatusCode,
+			Header:     t.Redactor.RedactHeader(resp.Header),
+			Body:       string(t.Redactor.RedactJSON(respBody)),
+		},
+	}
+
+	t.mu.Lock()
+	t.cassette.Interactions = append(t.cassette.Interactions, interaction)
+	t.mu.Unlock()
+
+	return resp, nil
+}
+
+// Cassette returns a copy of the interactions recorded so far.
+func (t *RecordingTransport) Cassette() *Cassette {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return &Cassette{
+		Interactions: append([]Interaction(nil), t.cassette.Interactions...),
+	}
+}
+
+// Save writes the interactions recorded so far to a cassette file.
+func (t *RecordingTransport) Save(filename string) error {
+	return t.Cassette().Save(filename)
+}
+
+// ReplayTransport is an http.RoundTripper that serves responses from a
+// Cassette instead of sending requests.  A request matches an interaction
+// when its method, path, query and scrubbed body are the same; each
+// interaction is served once, in recorded order.  Requests that match no
+// remaining interaction fail.
+type ReplayTransport struct {
+	// Redactor scrubs request bodies before matching, the same way they were
+	// scrubbed when recorded.  If nil, arpio.DefaultRedactor is used.
+	Redactor *arpio.Redactor
+
+	mu       sync.Mutex
+	cassette *Cassette
+	used     []bool
+}
+
+// NewReplayTransport creates a ReplayTransport that serves the
+// interactions in cassette.
+func NewReplayTransport(cassette *Cassette) *ReplayTransport {
+	return &ReplayTransport{
+		cassette: cassette,
+		used:     make([]bool, len(cassette.Interactions)),
+	}
+}
+
+// RoundTrip serves the response of the first unused matching interaction.
+func (t *ReplayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil {
+		var err error
+		reqBody, err = ioutil.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	key := requestKey(req.Method, req.URL.RequestURI(), string(t.Redactor.RedactJSON(reqBody)))
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for i, interaction := range t.cassette.Interactions {
+		r := interaction.Request
+		if t.used[i] || requestKey(r.Method, r.URL, r.Body) != key {
+			continue
+		}
+		t.used[i] = true
+
+		header := http.Header{}
+		for k, v := range interaction.Response.Header {
+			header[k] = append([]string(nil), v...)
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", interaction.Response.StatusCode, http.StatusText(interaction.Response.StatusCode)),
+			StatusCode:    interaction.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          ioutil.NopCloser(bytes.NewReader([]byte(interaction.Response.Body))),
+			ContentLength: int64(len(interaction.Response.Body)),
+			Request:       req,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("arpiotest: no recorded interaction matches %s %s",
+		req.Method, req.URL.RequestURI())
+}
+
+// Unused returns the interactions that have not been replayed yet.
+func (t *ReplayTransport) Unused() []Interaction {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var unused []Interaction
+	for i, interaction := range t.cassette.Interactions {
+		if !t.used[i] {
+			unused = append(unused, interaction)
+		}
+	}
+	return unused
+}
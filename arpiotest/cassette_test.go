@@ -0,0 +1,101 @@
+package arpiotest
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	arpio "github.com/arpio/arpio-client-go"
+)
+
+const testResourcesJSON = `[
+	{
+		"arn": "arn:aws:rds:us-east-1:111111111111:cluster:db",
+		"type": "AWS::RDS::DBCluster",
+		"tags": {"env": "prod"},
+		"extras": [
+			{"type": "kmsKey", "environment": "source", "kmsKeyArn": "arn:aws:kms:us-east-1:111111111111:key/k"},
+			{"type": "rdsDbClusterSnapshot", "environment": "target", "dbClusterSnapshotArn": "arn:aws:rds:us-west-2:222222222222:cluster-snapshot:s"}
+		]
+	}
+]`
+
+func TestRecordAndReplay(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+
+	var resources []arpio.StagedResource
+	err := json.Unmarshal([]byte(testResourcesJSON), &resources)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sp := arpio.NewSyncPair("111111111111", "us-east-1", "222222222222", "us-west-2")
+	rp := s.SeedRecoveryPoint("acct", sp, arpio.RecoveryPoint{})
+	s.SeedResources("acct", sp, rp.RecoveryPointID, resources)
+
+	// Record
+	recorder := NewRecordingTransport(nil)
+	c, err := s.Client("acct", arpio.WithTransport(recorder))
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorded, err := c.ListRecoveryPointResources(sp, rp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "arpio-cassette")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "resources.json")
+	err = recorder.Save(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(b), apiKeyHeader) {
+		t.Fatalf("the API key was recorded")
+	}
+
+	// Replay, without the server
+	cassette, err := LoadCassette(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replayer := NewReplayTransport(cassette)
+	c, err = arpio.NewClient("http://replay.invalid", "id", "secret", "acct",
+		arpio.WithTransport(replayer))
+	if err != nil {
+		t.Fatal(err)
+	}
+	replayed, err := c.ListRecoveryPointResources(sp, rp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range recorded {
+		recorded[i].RawExtras = nil
+		replayed[i].RawExtras = nil
+	}
+	if !reflect.DeepEqual(recorded, replayed) {
+		t.Fatalf("%+v != %+v", recorded, replayed)
+	}
+	if len(replayer.Unused()) != 0 {
+		t.Fatalf("interactions were not replayed")
+	}
+
+	// Each interaction is served once
+	_, err = c.ListRecoveryPointResources(sp, rp)
+	if err == nil || !strings.Contains(err.Error(), "no recorded interaction") {
+		t.Fatalf("expected an unmatched request error, got %v", err)
+	}
+}
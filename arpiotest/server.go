@@ -0,0 +1,509 @@
+// Package arpiotest provides an in-memory fake of the Arpio API for testing
+// code built on the arpio client.
+package arpiotest
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	arpio "github.com/arpio/arpio-client-go"
+)
+
+// Credentials the fake server accepts from clients created with Client.
+const (
+	APIKeyID     = "test-api-key-id"
+	APIKeySecret = "test-api-key-secret"
+)
+
+// apiKeyHeader is the X-Api-Key header value clients must send.
+var apiKeyHeader = base64.StdEncoding.EncodeToString([]byte(APIKeyID + ":" + APIKeySecret))
+
+// Failure describes an error the Server returns instead of handling
+// matching requests.
+type Failure struct {
+	// Method is the HTTP method to match, or empty to match any method.
+	Method string
+
+	// Path is a path.Match pattern for the request path, relative to the
+	// server URL (for example "/accounts/*/applications"), or empty to match
+	// any path.
+	Path string
+
+	// Status is the HTTP status to respond with.
+	Status int
+
+	// Message is the ErrorResponse message to respond with.
+	Message string
+
+	// Count is the number of requests to fail, or 0 to fail every matching
+	// request until ClearFailures is called.
+	Count int
+}
+
+func (f Failure) matches(r *http.Request) bool {
+	if f.Method != "" && f.Method != r.Method {
+		return false
+	}
+	if f.Path != "" {
+		ok, err := path.Match(f.Path, r.URL.Path)
+		if err != nil || !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Server is an in-memory fake of the Arpio API.  It implements the
+// application, recovery point and recovery point resource endpoints the
+// arpio Client uses.  It is safe for concurrent use.
+type Server struct {
+	// URL is the base URL of the fake API, suitable for arpio.NewClient.
+	URL string
+
+	server *httptest.Server
+
+	mu             sync.Mutex
+	apps           map[string]arpio.App
+	recoveryPoints map[string]map[string]arpio.RecoveryPoint
+	resources      map[string][]arpio.StagedResource
+	failures       []*Failure
+	latency        time.Duration
+	nextID         int
+
+	// Now returns the current time; tests may replace it to control the
+	// CreatedAt timestamps the server assigns.
+	Now func() time.Time
+}
+
+// NewServer starts a Server.  Callers should Close it when finished.
+func NewServer() *Server {
+	s := &Server{
+		apps:           map[string]arpio.App{},
+		recoveryPoints: map[string]map[string]arpio.RecoveryPoint{},
+		resources:      map[string][]arpio.StagedResource{},
+		Now:            time.Now,
+	}
+	s.server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	s.URL = s.server.URL
+	return s
+}
+
+// Close shuts down the Server.
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// Client creates an arpio Client connected to the Server for the specified
+// Arpio account.
+func (s *Server) Client(accountID string, opts ...arpio.Option) (*arpio.Client, error) {
+	return arpio.NewClient(s.URL, APIKeyID, APIKeySecret, accountID, opts...)
+}
+
+// SeedApp stores app as if it had been created through the API, assigning
+// an AppID, CreatedAt and SyncPhase if they are not set.  The stored app is
+// returned.
+func (s *Server) SeedApp(app arpio.App) arpio.App {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if app.AppID == "" {
+		app.AppID = s.newID("app")
+	}
+	if app.CreatedAt.IsZero() {
+		app.CreatedAt = s.Now().UTC()
+	}
+	if app.SyncPhase == "" {
+		app.SyncPhase = arpio.SyncPhaseInitializing
+	}
+	if app.SelectionRules == nil {
+		app.SelectionRules = []arpio.SelectionRule{}
+	}
+	app.RawSelectionRules = nil
+	s.apps[app.AppID] = app
+	return app
+}
+
+// SetAppSyncPhase changes the sync phase of the app with the specified ID,
+// as the Arpio service does while it protects the app.  It reports whether
+// the app exists.
+func (s *Server) SetAppSyncPhase(appID string, phase arpio.SyncPhase) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	app, ok := s.apps[appID]
+	if ok {
+		app.SyncPhase = phase
+		s.apps[appID] = app
+	}
+	return ok
+}
+
+// Apps returns the apps stored for the specified account, ordered by
+// creation time.
+func (s *Server) Apps(accountID string) []arpio.App {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.listApps(accountID)
+}
+
+// SeedRecoveryPoint stores a recovery point for the sync pair in the
+// specified account, assigning a RecoveryPointID if it is not set.  The
+// stored recovery point is returned.
+func (s *Server) SeedRecoveryPoint(accountID string, syncPair arpio.SyncPair, rp arpio.RecoveryPoint) arpio.RecoveryPoint {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if rp.RecoveryPointID == "" {
+		rp.RecoveryPointID = s.newID("rp")
+	}
+	key := syncPairKey(accountID, syncPair)
+	if s.recoveryPoints[key] == nil {
+		s.recoveryPoints[key] = map[string]arpio.RecoveryPoint{}
+	}
+	s.recoveryPoints[key][rp.RecoveryPointID] = rp
+	return rp
+}
+
+// SeedResources sets the staged resources of a recovery point.
+func (s *Server) SeedResources(accountID string, syncPair arpio.SyncPair, recoveryPointID string, resources []arpio.StagedResource) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := syncPairKey(accountID, syncPair) + "/" + recoveryPointID
+	s.resources[key] = append([]arpio.StagedResource(nil), resources...)
+}
+
+// InjectFailure makes the Server fail requests matching f.  Failures are
+// checked in the order they were injected.
+func (s *Server) InjectFailure(f Failure) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = append(s.failures, &f)
+}
+
+// ClearFailures removes all injected failures.
+func (s *Server) ClearFailures() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = nil
+}
+
+// SetLatency delays every response by d.
+func (s *Server) SetLatency(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latency = d
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	latency := s.latency
+	failure := s.takeFailure(r)
+	s.mu.Unlock()
+
+	if latency > 0 {
+		t := time.NewTimer(latency)
+		select {
+		case <-r.Context().Done():
+			t.Stop()
+			return
+		case <-t.C:
+		}
+	}
+	if failure != nil {
+		writeError(w, failure.Status, failure.Message)
+		return
+	}
+	if r.Header.Get("X-Api-Key") != apiKeyHeader {
+		writeError(w, http.StatusUnauthorized, "invalid API key")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.route(w, r)
+}
+
+// takeFailure finds the first injected failure matching r and uses it up.
+func (s *Server) takeFailure(r *http.Request) *Failure {
+	for i, f := range s.failures {
+		if !f.matches(r) {
+			continue
+		}
+		if f.Count > 0 {
+			f.Count--
+			if f.Count == 0 {
+				s.failures = append(s.failures[:i], s.failures[i+1:]...)
+			}
+		}
+		return f
+	}
+	return nil
+}
+
+// route dispatches a request.  The caller must hold s.mu.
+func (s *Server) route(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) < 3 || parts[0] != "accounts" {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	accountID := parts[1]
+
+	switch {
+	case parts[2] == "applications" && len(parts) == 3:
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, http.StatusOK, s.listApps(accountID))
+		case http.MethodPost:
+			s.createApp(w, r, accountID)
+		default:
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		}
+	case parts[2] == "applications" && len(parts) == 4:
+		switch r.Method {
+		case http.MethodGet:
+			s.getApp(w, accountID, parts[3])
+		case http.MethodPut:
+			s.updateApp(w, r, accountID, parts[3])
+		case http.MethodDelete:
+			s.deleteApp(w, accountID, parts[3])
+		default:
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		}
+	case parts[2] == "syncPairs" && len(parts) >= 8 && parts[7] == "recoveryPoints":
+		syncPair := arpio.NewSyncPair(parts[3], parts[4], parts[5], parts[6])
+		key := syncPairKey(accountID, syncPair)
+		switch {
+		case len(parts) == 8 && r.Method == http.MethodGet:
+			s.listRecoveryPoints(w, r, key)
+		case len(parts) == 9 && r.Method == http.MethodGet:
+			s.getRecoveryPoint(w, key, parts[8])
+		case len(parts) == 9 && r.Method == http.MethodPut:
+			s.updateRecoveryPoint(w, r, key, parts[8])
+		case len(parts) == 10 && parts[9] == "resources" && r.Method == http.MethodGet:
+			s.listResources(w, key, parts[8])
+		default:
+			writeError(w, http.StatusNotFound, "not found")
+		}
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+func (s *Server) listApps(accountID string) []arpio.App {
+	apps := []arpio.App{}
+	for _, a := range s.apps {
+		if a.AccountID == accountID {
+			apps = append(apps, a)
+		}
+	}
+	sort.Slice(apps, func(i, j int) bool {
+		if !apps[i].CreatedAt.Equal(apps[j].CreatedAt) {
+			return apps[i].CreatedAt.Before(apps[j].CreatedAt)
+		}
+		return apps[i].AppID < apps[j].AppID
+	})
+	return apps
+}
+
+func (s *Server) createApp(w http.ResponseWriter, r *http.Request, accountID string) {
+	var app arpio.App
+	if !readJSON(w, r, &app) {
+		return
+	}
+	app.AccountID = accountID
+	if msg := validateApp(app); msg != "" {
+		writeError(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	app.AppID = s.newID("app")
+	app.CreatedAt = s.Now().UTC().Truncate(time.Second)
+	app.SyncPhase = arpio.SyncPhaseInitializing
+	app.RawSelectionRules = nil
+	s.apps[app.AppID] = app
+	writeJSON(w, http.StatusCreated, app)
+}
+
+func (s *Server) getApp(w http.ResponseWriter, accountID, appID string) {
+	app, ok := s.apps[appID]
+	if !ok || app.AccountID != accountID {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("application %s not found", appID))
+		return
+	}
+	writeJSON(w, http.StatusOK, app)
+}
+
+// updateApp updates the mutable properties of an app.  Changes to other
+// properties are ignored.
+func (s *Server) updateApp(w http.ResponseWriter, r *http.Request, accountID, appID string) {
+	app, ok := s.apps[appID]
+	if !ok || app.AccountID != accountID {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("application %s not found", appID))
+		return
+	}
+
+	var update arpio.App
+	if !readJSON(w, r, &update) {
+		return
+	}
+	app.Name = update.Name
+	app.NotificationEmails = update.NotificationEmails
+	app.RPO = update.RPO
+	app.SelectionRules = update.SelectionRules
+	if msg := validateApp(app); msg != "" {
+		writeError(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	s.apps[appID] = app
+	writeJSON(w, http.StatusOK, app)
+}
+
+func (s *Server) deleteApp(w http.ResponseWriter, accountID, appID string) {
+	app, ok := s.apps[appID]
+	if !ok || app.AccountID != accountID {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("application %s not found", appID))
+		return
+	}
+	delete(s.apps, appID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) listRecoveryPoints(w http.ResponseWriter, r *http.Request, key string) {
+	var start, end *time.Time
+	for name, dest := range map[string]**time.Time{"timestampStart": &start, "timestampEnd": &end} {
+		v := r.URL.Query().Get(name)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid %s: %s", name, v))
+			return
+		}
+		*dest = &t
+	}
+
+	rps := []arpio.RecoveryPoint{}
+	for _, rp := range s.recoveryPoints[key] {
+		if start != nil && rp.Timestamp.Before(*start) {
+			continue
+		}
+		if end != nil && rp.Timestamp.After(*end) {
+			continue
+		}
+		rps = append(rps, rp)
+	}
+	sort.Slice(rps, func(i, j int) bool {
+		return rps[i].Timestamp.Before(rps[j].Timestamp)
+	})
+	writeJSON(w, http.StatusOK, rps)
+}
+
+func (s *Server) getRecoveryPoint(w http.ResponseWriter, key, recoveryPointID string) {
+	rp, ok := s.recoveryPoints[key][recoveryPointID]
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("recovery point %s not found", recoveryPointID))
+		return
+	}
+	writeJSON(w, http.StatusOK, rp)
+}
+
+// updateRecoveryPoint updates the mutable properties of a recovery point.
+func (s *Server) updateRecoveryPoint(w http.ResponseWriter, r *http.Request, key, recoveryPointID string) {
+	rp, ok := s.recoveryPoints[key][recoveryPointID]
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("recovery point %s not found", recoveryPointID))
+		return
+	}
+
+	var update arpio.RecoveryPoint
+	if !readJSON(w, r, &update) {
+		return
+	}
+	rp.Protected = update.Protected
+
+	s.recoveryPoints[key][recoveryPointID] = rp
+	writeJSON(w, http.StatusOK, rp)
+}
+
+func (s *Server) listResources(w http.ResponseWriter, key, recoveryPointID string) {
+	if _, ok := s.recoveryPoints[key][recoveryPointID]; !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("recovery point %s not found", recoveryPointID))
+		return
+	}
+	resources := s.resources[key+"/"+recoveryPointID]
+	if resources == nil {
+		resources = []arpio.StagedResource{}
+	}
+	writeJSON(w, http.StatusOK, resources)
+}
+
+// newID generates a unique ID.  The caller must hold s.mu.
+func (s *Server) newID(prefix string) string {
+	s.nextID++
+	return fmt.Sprintf("%s-%08d", prefix, s.nextID)
+}
+
+// validateApp checks the properties the Arpio API requires, returning an
+// error message if the app is invalid.
+func validateApp(app arpio.App) string {
+	switch {
+	case app.Name == "":
+		return "name is required"
+	case app.AppType != arpio.StandardAppType && app.AppType != arpio.TerraformAppType:
+		return fmt.Sprintf("invalid application type: %q", app.AppType)
+	case app.SourceAwsAccountID == "" || app.SourceRegion == "":
+		return "source AWS account and region are required"
+	case app.TargetAwsAccountID == "" || app.TargetRegion == "":
+		return "target AWS account and region are required"
+	case app.RPO < 0:
+		return "rpo must not be negative"
+	}
+	return ""
+}
+
+func syncPairKey(accountID string, syncPair arpio.SyncPair) string {
+	return accountID + "/" + syncPair.String()
+}
+
+func readJSON(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	b, err := ioutil.ReadAll(r.Body)
+	if err == nil {
+		err = json.Unmarshal(b, v)
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %s", err))
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(b)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	b, _ := json.Marshal(arpio.ErrorResponse{Message: message})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(b)
+}
@@ -0,0 +1,191 @@
+package arpiotest
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	arpio "github.com/arpio/arpio-client-go"
+)
+
+func newTestApp(c *arpio.Client, name string) arpio.App {
+	app := c.NewApp()
+	app.Name = name
+	app.RPO = 3600
+	app.SourceAwsAccountID = "111111111111"
+	app.SourceRegion = "us-east-1"
+	app.TargetAwsAccountID = "222222222222"
+	app.TargetRegion = "us-west-2"
+	app.SelectionRules = []arpio.SelectionRule{arpio.NewTagRule("env", "prod")}
+	return app
+}
+
+func TestAppCRUD(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	c, err := s.Client("acct")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	created, err := c.CreateApp(newTestApp(c, "app"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.AppID == "" || created.CreatedAt.IsZero() || created.AccountID != "acct" ||
+		created.SyncPhase != arpio.SyncPhaseInitializing {
+		t.Fatalf("server-managed fields were not set: %+v", created)
+	}
+
+	if !s.SetAppSyncPhase(created.AppID, arpio.SyncPhaseSynced) {
+		t.Fatal("SetAppSyncPhase did not find the app")
+	}
+	app, err := c.GetApp(created.AppID)
+	if err != nil || app.SyncPhase != arpio.SyncPhaseSynced {
+		t.Fatalf("sync phase was not set: %+v, %v", app, err)
+	}
+	created.SyncPhase = app.SyncPhase
+
+	created.Name = "renamed"
+	created.SourceRegion = "eu-west-1"
+	updated, err := c.UpdateApp(created)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Name != "renamed" || updated.SourceRegion != "us-east-1" {
+		t.Fatalf("unexpected update result: %+v", updated)
+	}
+
+	app, err = c.GetAppByName("renamed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if app == nil || app.AppID != created.AppID || len(app.SelectionRules) != 1 {
+		t.Fatalf("unexpected app: %+v", app)
+	}
+
+	err = c.DeleteApp(created.AppID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	app, err = c.GetApp(created.AppID)
+	if err != nil || app != nil {
+		t.Fatalf("app was not deleted: %+v, %v", app, err)
+	}
+}
+
+func TestCreateAppValidation(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	c, err := s.Client("acct")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = c.CreateApp(c.NewApp())
+	if err == nil || err.Error() != "name is required" {
+		t.Fatalf("expected a validation error, got %v", err)
+	}
+}
+
+func TestRecoveryPoints(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	c, err := s.Client("acct")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sp := arpio.NewSyncPair("111111111111", "us-east-1", "222222222222", "us-west-2")
+	t0 := time.Date(2021, 9, 1, 0, 0, 0, 0, time.UTC)
+	s.SeedRecoveryPoint("acct", sp, arpio.RecoveryPoint{Timestamp: t0})
+	latest := s.SeedRecoveryPoint("acct", sp, arpio.RecoveryPoint{Timestamp: t0.Add(time.Hour)})
+	s.SeedResources("acct", sp, latest.RecoveryPointID, []arpio.StagedResource{
+		{ARN: "arn:aws:s3:::bucket", Type: "AWS::S3::Bucket"},
+	})
+
+	rp, err := c.FindLatestRecoveryPoint(sp, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rp == nil || rp.RecoveryPointID != latest.RecoveryPointID {
+		t.Fatalf("unexpected recovery point: %+v", rp)
+	}
+
+	end := t0.Add(time.Minute)
+	rps, err := c.ListRecoveryPoints(sp, nil, &end)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rps) != 1 {
+		t.Fatalf("%d recovery points != 1", len(rps))
+	}
+
+	protected, err := c.ProtectRecoveryPoint(sp, *rp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !protected.Protected {
+		t.Fatalf("recovery point was not protected")
+	}
+
+	srs, err := c.ListRecoveryPointResources(sp, *rp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(srs) != 1 || srs[0].ARN != "arn:aws:s3:::bucket" {
+		t.Fatalf("unexpected resources: %+v", srs)
+	}
+
+	missing, err := c.GetRecoveryPoint(sp, "nope")
+	if err != nil || missing != nil {
+		t.Fatalf("expected not found, got %+v, %v", missing, err)
+	}
+}
+
+func TestInjectFailureAndLatency(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	c, err := s.Client("acct", arpio.WithRetryPolicy(arpio.RetryPolicy{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.InjectFailure(Failure{
+		Method: http.MethodGet,
+		Path:   "/accounts/*/applications",
+		Status: http.StatusTooManyRequests,
+		Count:  1,
+	})
+	_, err = c.ListApps()
+	if !arpio.IsRateLimited(err) {
+		t.Fatalf("expected rate limited error, got %v", err)
+	}
+	_, err = c.ListApps()
+	if err != nil {
+		t.Fatalf("failure was not used up: %v", err)
+	}
+
+	s.SetLatency(time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = c.ListAppsWithContext(ctx)
+	if err == nil {
+		t.Fatal("expected the request to time out")
+	}
+}
+
+func TestRejectsBadAPIKey(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	c, err := arpio.NewClient(s.URL, "wrong", "key", "acct")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = c.ListApps()
+	if !arpio.IsUnauthorized(err) {
+		t.Fatalf("expected unauthorized error, got %v", err)
+	}
+}
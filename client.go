@@ -2,13 +2,13 @@ package arpio
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
-	"log"
 	"net/http"
 	"net/url"
 	"os"
@@ -34,6 +34,22 @@ type Client struct {
 	APIKeyID     string
 	APIKeySecret string
 	HTTPClient   *http.Client
+	RetryPolicy  RetryPolicy
+	RateLimiter  RateLimiter
+
+	// UserAgentSuffix is appended to the User-Agent header sent with every
+	// request, to identify the embedding application.
+	UserAgentSuffix string
+
+	// Logger receives the Client's log entries.  If nil, nothing is logged.
+	Logger Logger
+
+	// Redactor scrubs request and response data before it is logged or
+	// attached to an APIError.  If nil, DefaultRedactor is used.
+	Redactor *Redactor
+
+	// Middleware wraps every API request, outermost first.
+	Middleware []Middleware
 }
 
 // ErrorResponse contains the data the Arpio API returns with most error statuses.
@@ -43,7 +59,9 @@ type ErrorResponse struct {
 }
 
 // NewClient creates a Client using the specified connection information.
-func NewClient(apiURL, apiKeyID, apiKeySecret, accountID string) (*Client, error) {
+// The options are applied in order after the defaults are set up, so later
+// options override earlier ones.
+func NewClient(apiURL, apiKeyID, apiKeySecret, accountID string, opts ...Option) (*Client, error) {
 	if apiURL == "" {
 		return nil, fmt.Errorf("apiURL is required")
 	}
@@ -71,6 +89,13 @@ func NewClient(apiURL, apiKeyID, apiKeySecret, accountID string) (*Client, error
 			Transport: tr,
 			Timeout:   60 * time.Second,
 		},
+		RetryPolicy: DefaultRetryPolicy(),
+		Redactor:    DefaultRedactor(),
+	}
+	for _, opt := range opts {
+		if err := opt(&client); err != nil {
+			return nil, err
+		}
 	}
 	return &client, nil
 }
@@ -81,100 +106,166 @@ func (c Client) buildApiURL(relativeURL string) (u *url.URL, err error) {
 	return url.Parse(fmt.Sprintf("%s/%s", apiURL, relativeURL))
 }
 
-func (c Client) apiPost(relativeURL string, requestBody interface{}, responseBody interface{}) (status int, err error) {
-	return c.doApiRequest("POST", relativeURL, requestBody, responseBody)
+func (c Client) apiPost(ctx context.Context, relativeURL string, requestBody interface{}, responseBody interface{}) (status int, err error) {
+	return c.doApiRequest(ctx, "POST", relativeURL, requestBody, responseBody)
 }
 
-func (c Client) apiPut(relativeURL string, requestBody interface{}, responseBody interface{}) (status int, err error) {
-	return c.doApiRequest("PUT", relativeURL, requestBody, responseBody)
+func (c Client) apiPut(ctx context.Context, relativeURL string, requestBody interface{}, responseBody interface{}) (status int, err error) {
+	return c.doApiRequest(ctx, "PUT", relativeURL, requestBody, responseBody)
 }
 
-func (c Client) apiGet(relativeURL string, responseBody interface{}) (status int, err error) {
-	return c.doApiRequest("GET", relativeURL, nil, responseBody)
+func (c Client) apiGet(ctx context.Context, relativeURL string, responseBody interface{}) (status int, err error) {
+	return c.doApiRequest(ctx, "GET", relativeURL, nil, responseBody)
 }
 
-func (c Client) apiDelete(relativeURL string, responseBody interface{}) (status int, err error) {
-	return c.doApiRequest("DELETE", relativeURL, nil, responseBody)
+func (c Client) apiDelete(ctx context.Context, relativeURL string, responseBody interface{}) (status int, err error) {
+	return c.doApiRequest(ctx, "DELETE", relativeURL, nil, responseBody)
 }
 
 // Perform one Arpio API request using the specified HTTP method and optional
 // request body (which will be marshaled to JSON).  If a non-nil response body
 // is specified, the result will be unmarshaled from JSON and written to it.
 //
-// An error is included in the returned values if the response status
+// An *APIError is included in the returned values if the response status
 // code >= 400 (responseBody does not receive the response body when an error
-// is returned).
-func (c Client) doApiRequest(method, relativeURL string, requestBody interface{}, responseBody interface{}) (status int, err error) {
-	u, err := c.buildApiURL(relativeURL)
+// is returned).  The request is bound to ctx, so cancelling ctx or reaching
+// its deadline aborts the request.
+//
+// The request passes through the Client's middleware chain (see
+// roundTripper).
+func (c Client) doApiRequest(ctx context.Context, method, relativeURL string, requestBody interface{}, responseBody interface{}) (status int, err error) {
+	req := &APIRequest{
+		Method:      method,
+		RelativeURL: relativeURL,
+		Body:        requestBody,
+		Header:      http.Header{},
+	}
+	resp, err := c.roundTripper()(ctx, req)
+	if resp != nil {
+		status = resp.StatusCode
+	}
 	if err != nil {
 		return status, err
 	}
 
+	if responseBody != nil {
+		err = json.Unmarshal(resp.Body, responseBody)
+		if err != nil {
+			c.log(LevelDebug, "Error unmarshaling response body",
+				LogFieldMethod, method,
+				LogFieldPath, relativeURL,
+				LogFieldStatus, status,
+				LogFieldRequestID, requestIDFromHeader(resp.Header),
+				LogFieldBody, string(c.Redactor.RedactJSON(resp.Body)),
+				LogFieldError, err)
+			return status, err
+		}
+	}
+	return status, nil
+}
+
+// roundTripper composes the Client's middleware chain.  From the outside in,
+// a request passes through the Client's Middleware (in order), retries
+// according to RetryPolicy, waits for the RateLimiter (per attempt), is
+// logged (per attempt), and is finally sent.
+func (c Client) roundTripper() RoundTripFunc {
+	rt := RoundTripFunc(c.send)
+	rt = LoggingMiddleware(c.Logger, c.Redactor)(rt)
+	if c.RateLimiter != nil {
+		rt = RateLimitMiddleware(c.RateLimiter)(rt)
+	}
+	rt = RetryMiddleware(c.RetryPolicy, c.Logger)(rt)
+	for i := len(c.Middleware) - 1; i >= 0; i-- {
+		rt = c.Middleware[i](rt)
+	}
+	return rt
+}
+
+// send performs a single HTTP attempt of an Arpio API request and reads the
+// complete response body.  Error statuses are returned as an *APIError
+// along with the response.
+func (c Client) send(ctx context.Context, r *APIRequest) (*APIResponse, error) {
+	u, err := c.buildApiURL(r.RelativeURL)
+	if err != nil {
+		return nil, err
+	}
+
 	userAgent := fmt.Sprintf("%s/%s/%s", userAgentPrefix, Version, Commit)
+	if c.UserAgentSuffix != "" {
+		userAgent = fmt.Sprintf("%s %s", userAgent, c.UserAgentSuffix)
+	}
 	apiKeyHeader := buildApiKeyHeader(c.APIKeyID, c.APIKeySecret)
 
-	req := &http.Request{
-		Method: method,
+	req := (&http.Request{
+		Method: r.Method,
 		URL:    u,
 		Header: map[string][]string{
 			"Accept":     {"*/*"},
 			"User-Agent": {userAgent},
 			"X-Api-Key":  {apiKeyHeader},
 		},
+	}).WithContext(ctx)
+	for k, v := range r.Header {
+		req.Header[k] = v
 	}
-	if requestBody != nil {
-		requestJson, err := json.Marshal(requestBody)
+	if r.Body != nil {
+		requestJson, err := json.Marshal(r.Body)
 		if err != nil {
-			return status, err
+			return nil, err
 		}
-		log.Printf("[TRACE] %s", requestJson)
 		req.Body = ioutil.NopCloser(bytes.NewReader(requestJson))
 		req.Header.Set("Content-Type", "application/json")
 		req.Header.Set("Content-Length", fmt.Sprintf("%d", len(requestJson)))
 	}
 
-	resp, err := c.HTTPClient.Do(req)
+	httpResp, err := c.HTTPClient.Do(req)
 	if err != nil {
-		return status, err
+		return nil, err
 	}
-	status = resp.StatusCode
 	defer func(b io.ReadCloser) {
 		err := b.Close()
 		if err != nil {
-			log.Printf("[INFO] Error closing response body: %s", err)
+			c.log(LevelInfo, "Error closing response body", LogFieldError, err)
 		}
-	}(resp.Body)
+	}(httpResp.Body)
 
-	body, err := ioutil.ReadAll(resp.Body)
+	body, err := ioutil.ReadAll(httpResp.Body)
 	if err != nil {
-		return status, err
+		return nil, err
+	}
+	resp := &APIResponse{
+		StatusCode: httpResp.StatusCode,
+		Header:     httpResp.Header,
+		Body:       body,
 	}
 
 	// Arpio API errors come back in a standard format, as JSON.  Try to unmarshal
 	// the response body as that error type in these cases so we can include those
 	// details in the error we return.
-	if status >= 400 {
+	if resp.StatusCode >= 400 {
 		var errorResponse ErrorResponse
 		err = json.Unmarshal(body, &errorResponse)
-
-		var msg string
 		if err != nil {
-			log.Printf("[WARN] Error unmarshaling response body as ErrorResponse: %s", err)
-			msg = fmt.Sprintf("Arpio API error: %s", body)
-		} else {
-			msg = errorResponse.Message
+			c.log(LevelWarn, "Error unmarshaling response body as ErrorResponse",
+				LogFieldMethod, r.Method,
+				LogFieldPath, r.RelativeURL,
+				LogFieldStatus, resp.StatusCode,
+				LogFieldRequestID, requestIDFromHeader(resp.Header),
+				LogFieldError, err)
+			return resp, newAPIError(resp.StatusCode, resp.Header, c.Redactor.RedactJSON(body), nil)
 		}
 
-		return status, fmt.Errorf(msg)
+		return resp, newAPIError(resp.StatusCode, resp.Header, c.Redactor.RedactJSON(body), &errorResponse)
 	}
 
-	if responseBody != nil {
-		err = json.Unmarshal(body, responseBody)
-		if err != nil {
-			return status, err
-		}
+	return resp, nil
+}
+
+// log writes an entry through the Client's Logger, if it has one.
+func (c Client) log(level LogLevel, msg string, keyvals ...interface{}) {
+	if c.Logger != nil {
+		c.Logger.Log(level, msg, keyvals...)
 	}
-	return status, err
 }
 
 // buildApiKeyHeader builds the value to use for the X-Api-Key header.
@@ -1,8 +1,8 @@
 package arpio
 
 import (
+	"context"
 	"fmt"
-	"log"
 	"net/http"
 	"time"
 )
@@ -21,9 +21,14 @@ func (c *Client) NewApp() (app App) {
 // CreateApp creates an application in the Arpio service in the account the
 // Client is configured to use.
 func (c *Client) CreateApp(a App) (created App, err error) {
+	return c.CreateAppWithContext(context.Background(), a)
+}
+
+// CreateAppWithContext is like CreateApp, but the request is bound to ctx.
+func (c *Client) CreateAppWithContext(ctx context.Context, a App) (created App, err error) {
 	u := fmt.Sprintf("/accounts/%s/applications", c.AccountID)
 
-	_, err = c.apiPost(u, a, &created)
+	_, err = c.apiPost(ctx, u, a, &created)
 	if err != nil {
 		return created, err
 	}
@@ -34,9 +39,14 @@ func (c *Client) CreateApp(a App) (created App, err error) {
 // ListApps lists all the applications in the account the Client is configured
 // to use.
 func (c *Client) ListApps() (apps []App, err error) {
+	return c.ListAppsWithContext(context.Background())
+}
+
+// ListAppsWithContext is like ListApps, but the request is bound to ctx.
+func (c *Client) ListAppsWithContext(ctx context.Context) (apps []App, err error) {
 	u := fmt.Sprintf("/accounts/%s/applications", c.AccountID)
 
-	_, err = c.apiGet(u, &apps)
+	_, err = c.apiGet(ctx, u, &apps)
 	if err != nil {
 		return nil, err
 	}
@@ -47,10 +57,15 @@ func (c *Client) ListApps() (apps []App, err error) {
 // GetApp gets the application with the specified ID in the account the Client
 // is configured to use.
 func (c *Client) GetApp(appID string) (app *App, err error) {
+	return c.GetAppWithContext(context.Background(), appID)
+}
+
+// GetAppWithContext is like GetApp, but the request is bound to ctx.
+func (c *Client) GetAppWithContext(ctx context.Context, appID string) (app *App, err error) {
 	u := c.appPath(appID)
 
 	var a App
-	status, err := c.apiGet(u, &a)
+	status, err := c.apiGet(ctx, u, &a)
 	if status == http.StatusNotFound {
 		return nil, nil
 	}
@@ -63,9 +78,14 @@ func (c *Client) GetApp(appID string) (app *App, err error) {
 
 // UpdateApp updates the mutable properties of the specified application.
 func (c *Client) UpdateApp(a App) (app App, err error) {
+	return c.UpdateAppWithContext(context.Background(), a)
+}
+
+// UpdateAppWithContext is like UpdateApp, but the request is bound to ctx.
+func (c *Client) UpdateAppWithContext(ctx context.Context, a App) (app App, err error) {
 	u := c.appPath(a.AppID)
 
-	_, err = c.apiPut(u, a, &app)
+	_, err = c.apiPut(ctx, u, a, &app)
 	if err != nil {
 		return app, err
 	}
@@ -76,9 +96,14 @@ func (c *Client) UpdateApp(a App) (app App, err error) {
 // DeleteApp deletes the application with the specified ID.  If the application
 // does not exist, no error is returned.
 func (c *Client) DeleteApp(appID string) error {
+	return c.DeleteAppWithContext(context.Background(), appID)
+}
+
+// DeleteAppWithContext is like DeleteApp, but the request is bound to ctx.
+func (c *Client) DeleteAppWithContext(ctx context.Context, appID string) error {
 	u := c.appPath(appID)
 
-	status, err := c.apiDelete(u, nil)
+	status, err := c.apiDelete(ctx, u, nil)
 	if status == http.StatusNotFound {
 		return nil
 	}
@@ -93,7 +118,13 @@ func (c *Client) DeleteApp(appID string) error {
 // are multiple applications with the name, an error is returned.  If no
 // applications exist with the name, a nil app is returned.
 func (c *Client) GetAppByName(name string) (app *App, err error) {
-	apps, err := c.ListApps()
+	return c.GetAppByNameWithContext(context.Background(), name)
+}
+
+// GetAppByNameWithContext is like GetAppByName, but the request is bound
+// to ctx.
+func (c *Client) GetAppByNameWithContext(ctx context.Context, name string) (app *App, err error) {
+	apps, err := c.ListAppsWithContext(ctx)
 	if err != nil {
 		return app, err
 	}
@@ -119,17 +150,26 @@ func (c *Client) GetAppByName(name string) (app *App, err error) {
 // until the timeout has elapsed.  An error is returned if no matching app
 // could be found.
 func (c *Client) MustGetAppByName(name string, timeout time.Duration) (app *App, err error) {
+	return c.MustGetAppByNameWithContext(context.Background(), name, timeout)
+}
+
+// MustGetAppByNameWithContext is like MustGetAppByName, but requests are
+// bound to ctx and waiting stops with ctx's error as soon as ctx is done.
+func (c *Client) MustGetAppByNameWithContext(ctx context.Context, name string, timeout time.Duration) (app *App, err error) {
 	const zeroDuration = time.Duration(0)
 	for timeoutAt := time.Now().Add(timeout); timeout == zeroDuration || time.Now().Before(timeoutAt); {
-		app, err = c.GetAppByName(name)
+		app, err = c.GetAppByNameWithContext(ctx, name)
 		if err != nil {
 			return app, err
 		}
 		if app != nil || timeout == zeroDuration {
 			break
 		}
-		log.Printf("[DEBUG] Waiting for a matching app to exist")
-		time.Sleep(AppPollPeriod)
+		c.log(LevelDebug, "Waiting for a matching app to exist", "name", name)
+		err = sleepContext(ctx, AppPollPeriod)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	// If we didn't find an app, prepare an error
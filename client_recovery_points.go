@@ -1,8 +1,8 @@
 package arpio
 
 import (
+	"context"
 	"fmt"
-	"log"
 	"net/http"
 	"net/url"
 	"time"
@@ -14,6 +14,12 @@ const RecoveryPointPollPeriod = 5 * time.Second
 // If either timestampStart or timestampEnd is nil, that timestamp is
 // unconstrained in that direction.
 func (c *Client) ListRecoveryPoints(syncPair SyncPair, timestampStart, timestampEnd *time.Time) (rps []RecoveryPoint, err error) {
+	return c.ListRecoveryPointsWithContext(context.Background(), syncPair, timestampStart, timestampEnd)
+}
+
+// ListRecoveryPointsWithContext is like ListRecoveryPoints, but the request
+// is bound to ctx.
+func (c *Client) ListRecoveryPointsWithContext(ctx context.Context, syncPair SyncPair, timestampStart, timestampEnd *time.Time) (rps []RecoveryPoint, err error) {
 	spURL := c.syncPairPath(syncPair)
 
 	v := url.Values{}
@@ -30,7 +36,7 @@ func (c *Client) ListRecoveryPoints(syncPair SyncPair, timestampStart, timestamp
 
 	u := fmt.Sprintf("%s/recoveryPoints%s", spURL, query)
 
-	_, err = c.apiGet(u, &rps)
+	_, err = c.apiGet(ctx, u, &rps)
 	if err != nil {
 		return rps, err
 	}
@@ -40,10 +46,16 @@ func (c *Client) ListRecoveryPoints(syncPair SyncPair, timestampStart, timestamp
 
 // GetRecoveryPoint gets the recovery point with the specified ID.
 func (c *Client) GetRecoveryPoint(syncPair SyncPair, recoveryPointID string) (rp *RecoveryPoint, err error) {
+	return c.GetRecoveryPointWithContext(context.Background(), syncPair, recoveryPointID)
+}
+
+// GetRecoveryPointWithContext is like GetRecoveryPoint, but the request is
+// bound to ctx.
+func (c *Client) GetRecoveryPointWithContext(ctx context.Context, syncPair SyncPair, recoveryPointID string) (rp *RecoveryPoint, err error) {
 	u := c.recoveryPointPath(syncPair, recoveryPointID)
 
 	var r RecoveryPoint
-	status, err := c.apiGet(u, &r)
+	status, err := c.apiGet(ctx, u, &r)
 	if status == http.StatusNotFound {
 		return nil, nil
 	}
@@ -56,9 +68,15 @@ func (c *Client) GetRecoveryPoint(syncPair SyncPair, recoveryPointID string) (rp
 
 // UpdateRecoveryPoint updates the mutable properties of the specified recovery point.
 func (c *Client) UpdateRecoveryPoint(syncPair SyncPair, rp RecoveryPoint) (updated RecoveryPoint, err error) {
+	return c.UpdateRecoveryPointWithContext(context.Background(), syncPair, rp)
+}
+
+// UpdateRecoveryPointWithContext is like UpdateRecoveryPoint, but the
+// request is bound to ctx.
+func (c *Client) UpdateRecoveryPointWithContext(ctx context.Context, syncPair SyncPair, rp RecoveryPoint) (updated RecoveryPoint, err error) {
 	u := c.recoveryPointPath(syncPair, rp.RecoveryPointID)
 
-	_, err = c.apiPut(u, rp, &updated)
+	_, err = c.apiPut(ctx, u, rp, &updated)
 	if err != nil {
 		return updated, err
 	}
@@ -68,10 +86,16 @@ func (c *Client) UpdateRecoveryPoint(syncPair SyncPair, rp RecoveryPoint) (updat
 
 // ListRecoveryPointResources lists all the staged resources in the specified recovery point.
 func (c *Client) ListRecoveryPointResources(syncPair SyncPair, rp RecoveryPoint) (srs []StagedResource, err error) {
+	return c.ListRecoveryPointResourcesWithContext(context.Background(), syncPair, rp)
+}
+
+// ListRecoveryPointResourcesWithContext is like ListRecoveryPointResources,
+// but the request is bound to ctx.
+func (c *Client) ListRecoveryPointResourcesWithContext(ctx context.Context, syncPair SyncPair, rp RecoveryPoint) (srs []StagedResource, err error) {
 	rpURL := c.recoveryPointPath(syncPair, rp.RecoveryPointID)
 	u := fmt.Sprintf("%s/resources", rpURL)
 
-	_, err = c.apiGet(u, &srs)
+	_, err = c.apiGet(ctx, u, &srs)
 	if err != nil {
 		return srs, err
 	}
@@ -85,8 +109,14 @@ func (c *Client) ListRecoveryPointResources(syncPair SyncPair, rp RecoveryPoint)
 // constrained. If no recovery points exist between the time constraints,
 // nil is returned.
 func (c *Client) FindLatestRecoveryPoint(syncPair SyncPair, timestampMin, timestampMax *time.Time) (rp *RecoveryPoint, err error) {
+	return c.FindLatestRecoveryPointWithContext(context.Background(), syncPair, timestampMin, timestampMax)
+}
+
+// FindLatestRecoveryPointWithContext is like FindLatestRecoveryPoint, but
+// the request is bound to ctx.
+func (c *Client) FindLatestRecoveryPointWithContext(ctx context.Context, syncPair SyncPair, timestampMin, timestampMax *time.Time) (rp *RecoveryPoint, err error) {
 	// List all recovery points
-	allRPs, err := c.ListRecoveryPoints(syncPair, timestampMin, timestampMax)
+	allRPs, err := c.ListRecoveryPointsWithContext(ctx, syncPair, timestampMin, timestampMax)
 	if err != nil {
 		return rp, err
 	}
@@ -106,17 +136,27 @@ func (c *Client) FindLatestRecoveryPoint(syncPair SyncPair, timestampMin, timest
 // find a matching recovery point until the timeout has elapsed.  An error is
 // returned if no matching recovery point could be found.
 func (c *Client) MustFindLatestRecoveryPoint(syncPair SyncPair, timestampMin, timestampMax *time.Time, timeout time.Duration) (rp *RecoveryPoint, err error) {
+	return c.MustFindLatestRecoveryPointWithContext(context.Background(), syncPair, timestampMin, timestampMax, timeout)
+}
+
+// MustFindLatestRecoveryPointWithContext is like MustFindLatestRecoveryPoint,
+// but requests are bound to ctx and waiting stops with ctx's error as soon
+// as ctx is done.
+func (c *Client) MustFindLatestRecoveryPointWithContext(ctx context.Context, syncPair SyncPair, timestampMin, timestampMax *time.Time, timeout time.Duration) (rp *RecoveryPoint, err error) {
 	const zeroDuration = time.Duration(0)
 	for timeoutAt := time.Now().Add(timeout); timeout == zeroDuration || time.Now().Before(timeoutAt); {
-		rp, err = c.FindLatestRecoveryPoint(syncPair, timestampMin, timestampMax)
+		rp, err = c.FindLatestRecoveryPointWithContext(ctx, syncPair, timestampMin, timestampMax)
 		if err != nil {
 			return rp, err
 		}
 		if rp != nil || timeout == zeroDuration {
 			break
 		}
-		log.Printf("[DEBUG] Waiting for a matching recovery point to exist")
-		time.Sleep(RecoveryPointPollPeriod)
+		c.log(LevelDebug, "Waiting for a matching recovery point to exist", "sync_pair", syncPair.String())
+		err = sleepContext(ctx, RecoveryPointPollPeriod)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	// If we didn't find a recovery point, prepare an error
@@ -153,6 +193,12 @@ func (c *Client) MustFindLatestRecoveryPoint(syncPair SyncPair, timestampMin, ti
 // ProtectRecoveryPoint sets the "Protected" attribute to true and updates the
 // recovery point in the Arpio service.
 func (c *Client) ProtectRecoveryPoint(syncPair SyncPair, recoveryPoint RecoveryPoint) (protected RecoveryPoint, err error) {
+	return c.ProtectRecoveryPointWithContext(context.Background(), syncPair, recoveryPoint)
+}
+
+// ProtectRecoveryPointWithContext is like ProtectRecoveryPoint, but the
+// request is bound to ctx.
+func (c *Client) ProtectRecoveryPointWithContext(ctx context.Context, syncPair SyncPair, recoveryPoint RecoveryPoint) (protected RecoveryPoint, err error) {
 	if recoveryPoint.Protected {
 		return recoveryPoint, nil
 	}
@@ -160,7 +206,7 @@ func (c *Client) ProtectRecoveryPoint(syncPair SyncPair, recoveryPoint RecoveryP
 	protected = recoveryPoint
 	protected.Protected = true
 
-	protected, err = c.UpdateRecoveryPoint(syncPair, protected)
+	protected, err = c.UpdateRecoveryPointWithContext(ctx, syncPair, protected)
 	if err != nil {
 		return protected, err
 	}
@@ -0,0 +1,97 @@
+package arpio
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newTestClient(t *testing.T, handler http.HandlerFunc) (*Client, *httptest.Server) {
+	server := httptest.NewServer(handler)
+	c, err := NewClient(server.URL, "id", "secret", "acct")
+	if err != nil {
+		server.Close()
+		t.Fatal(err)
+	}
+	return c, server
+}
+
+func TestClientContextCancel(t *testing.T) {
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	})
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := c.ListAppsWithContext(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+}
+
+func TestMustGetAppByNameContextCancel(t *testing.T) {
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("[]"))
+	})
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := c.MustGetAppByNameWithContext(ctx, "missing", time.Hour)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if time.Since(start) >= AppPollPeriod {
+		t.Fatalf("waiter did not stop when the context was done")
+	}
+}
+
+func TestAPIError(t *testing.T) {
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", "req-1")
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"message":"expired","authenticateUrl":"https://example.com/auth"}`))
+	})
+	defer server.Close()
+
+	_, err := c.ListApps()
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected *APIError, got %T", err)
+	}
+	if apiErr.StatusCode != http.StatusUnauthorized ||
+		apiErr.Message != "expired" ||
+		apiErr.AuthenticateURL != "https://example.com/auth" ||
+		apiErr.RequestID != "req-1" {
+		t.Fatalf("unexpected error fields: %+v", apiErr)
+	}
+	if err.Error() != "expired" {
+		t.Fatalf("%q != %q", err.Error(), "expired")
+	}
+	if !IsUnauthorized(err) || IsNotFound(err) {
+		t.Fatalf("wrong classification for %v", err)
+	}
+}
+
+func TestAPIErrorNonJSONBody(t *testing.T) {
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+		_, _ = w.Write([]byte("nope"))
+	})
+	defer server.Close()
+
+	_, err := c.ListApps()
+	if !IsConflict(err) {
+		t.Fatalf("expected conflict, got %v", err)
+	}
+	if err.Error() != "Arpio API error: nope" {
+		t.Fatalf("unexpected message %q", err.Error())
+	}
+}
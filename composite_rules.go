@@ -0,0 +1,222 @@
+package arpio
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	AllOfRuleType = "allOf"
+	AnyOfRuleType = "anyOf"
+	NotRuleType   = "not"
+)
+
+// compositeRule is implemented by selection rules built from other rules.
+type compositeRule interface {
+	subRules() []SelectionRule
+}
+
+// walkSelectionRules calls fn for each rule and, depth first, for the rules
+// nested in composite rules.  Nil rules are skipped.
+func walkSelectionRules(rules []SelectionRule, fn func(r SelectionRule)) {
+	for _, r := range rules {
+		if r == nil {
+			continue
+		}
+		fn(r)
+		if c, ok := r.(compositeRule); ok {
+			walkSelectionRules(c.subRules(), fn)
+		}
+	}
+}
+
+// AllOfRule matches resources that all of its rules match.  An AllOfRule
+// without rules matches nothing.
+type AllOfRule struct {
+	selectionRule
+	Rules []SelectionRule `json:"-"`
+}
+
+// NewAllOfRule creates an AllOfRule that will match resources that all of
+// the specified rules match.
+func NewAllOfRule(rules []SelectionRule) AllOfRule {
+	return AllOfRule{
+		selectionRule: selectionRule{RuleType: AllOfRuleType},
+		Rules:         rules,
+	}
+}
+
+func (r AllOfRule) MarshalJSON() ([]byte, error) {
+	return marshalRuleSet(r.RuleType, r.Rules)
+}
+
+func (r *AllOfRule) UnmarshalJSON(b []byte) (err error) {
+	r.RuleType, r.Rules, err = unmarshalRuleSet(b)
+	return err
+}
+
+// Matches reports whether every one of the rule's rules matches the
+// resource.  Nested rules that can't be evaluated locally don't match.
+func (r AllOfRule) Matches(resource StagedResource) bool {
+	if len(r.Rules) == 0 {
+		return false
+	}
+	for _, sub := range r.Rules {
+		if !matches(sub, resource) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r AllOfRule) subRules() []SelectionRule {
+	return r.Rules
+}
+
+// AnyOfRule matches resources that any of its rules match.  An AnyOfRule
+// without rules matches nothing.
+type AnyOfRule struct {
+	selectionRule
+	Rules []SelectionRule `json:"-"`
+}
+
+// NewAnyOfRule creates an AnyOfRule that will match resources that at
+// least one of the specified rules matches.
+func NewAnyOfRule(rules []SelectionRule) AnyOfRule {
+	return AnyOfRule{
+		selectionRule: selectionRule{RuleType: AnyOfRuleType},
+		Rules:         rules,
+	}
+}
+
+func (r AnyOfRule) MarshalJSON() ([]byte, error) {
+	return marshalRuleSet(r.RuleType, r.Rules)
+}
+
+func (r *AnyOfRule) UnmarshalJSON(b []byte) (err error) {
+	r.RuleType, r.Rules, err = unmarshalRuleSet(b)
+	return err
+}
+
+// Matches reports whether any of the rule's rules matches the resource.
+// Nested rules that can't be evaluated locally don't match.
+func (r AnyOfRule) Matches(resource StagedResource) bool {
+	for _, sub := range r.Rules {
+		if matches(sub, resource) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r AnyOfRule) subRules() []SelectionRule {
+	return r.Rules
+}
+
+// NotRule matches resources that its rule does not match.
+type NotRule struct {
+	selectionRule
+	Rule SelectionRule `json:"-"`
+
+	// JSON serialization helpers
+	RawRule json.RawMessage `json:"rule"`
+}
+
+// NewNotRule creates a NotRule that will match resources that the
+// specified rule does not match.
+func NewNotRule(rule SelectionRule) NotRule {
+	return NotRule{
+		selectionRule: selectionRule{RuleType: NotRuleType},
+		Rule:          rule,
+	}
+}
+
+func (r NotRule) MarshalJSON() ([]byte, error) {
+	// Use a type alias to avoid invoking this function recursively
+	type NotRuleDTO NotRule
+
+	var err error
+	r.RawRule, err = json.Marshal(r.Rule)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal((NotRuleDTO)(r))
+}
+
+func (r *NotRule) UnmarshalJSON(b []byte) error {
+	// Use a type alias to avoid invoking this function recursively
+	type NotRuleDTO NotRule
+	err := json.Unmarshal(b, (*NotRuleDTO)(r))
+	if err != nil {
+		return err
+	}
+
+	if isNullJSON(r.RawRule) {
+		return fmt.Errorf("%s rule has no rule", NotRuleType)
+	}
+	r.Rule, err = unmarshalSelectionRule(r.RawRule)
+	r.RawRule = nil
+	return err
+}
+
+// Matches reports whether the rule's rule does not match the resource.
+// If the nested rule can't be evaluated locally, nothing matches.
+func (r NotRule) Matches(resource StagedResource) bool {
+	if _, ok := r.Rule.(ResourceMatcher); !ok {
+		return false
+	}
+	return !matches(r.Rule, resource)
+}
+
+func (r NotRule) subRules() []SelectionRule {
+	if r.Rule == nil {
+		return nil
+	}
+	return []SelectionRule{r.Rule}
+}
+
+// matches evaluates rule against resource.  Rules that can't be evaluated
+// locally don't match.
+func matches(rule SelectionRule, resource StagedResource) bool {
+	m, ok := rule.(ResourceMatcher)
+	return ok && m.Matches(resource)
+}
+
+// ruleSetDTO is the JSON form of AllOfRule and AnyOfRule.
+type ruleSetDTO struct {
+	RuleType string            `json:"ruleType"`
+	RawRules []json.RawMessage `json:"rules"`
+}
+
+// marshalRuleSet marshals a rule built from a list of rules.
+func marshalRuleSet(ruleType string, rules []SelectionRule) ([]byte, error) {
+	raw, err := marshalSelectionRules(rules)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(ruleSetDTO{RuleType: ruleType, RawRules: raw})
+}
+
+// unmarshalRuleSet unmarshals a rule built from a list of rules, none of
+// which may be null.
+func unmarshalRuleSet(b []byte) (ruleType string, rules []SelectionRule, err error) {
+	var dto ruleSetDTO
+	err = json.Unmarshal(b, &dto)
+	if err != nil {
+		return "", nil, err
+	}
+	for i, raw := range dto.RawRules {
+		if isNullJSON(raw) {
+			return "", nil, fmt.Errorf("%s rule %d is null", dto.RuleType, i)
+		}
+	}
+
+	rules, err = unmarshalSelectionRules(dto.RawRules)
+	return dto.RuleType, rules, err
+}
+
+// isNullJSON reports whether raw is missing or JSON null.
+func isNullJSON(raw json.RawMessage) bool {
+	return len(bytes.TrimSpace(raw)) == 0 || string(bytes.TrimSpace(raw)) == "null"
+}
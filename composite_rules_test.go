@@ -0,0 +1,121 @@
+package arpio
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestCompositeRulesJSON(t *testing.T) {
+	orig := App{
+		SelectionRules: []SelectionRule{
+			NewAllOfRule([]SelectionRule{
+				NewTagRule("env", "prod"),
+				NewNotRule(NewAnyOfRule([]SelectionRule{
+					NewTagRule("tier", "scratch"),
+					NewArnRule([]string{"arn:a"}),
+				})),
+			}),
+		},
+	}
+	bytes, err := json.Marshal(orig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{"accountId":"","type":"","createdAt":"0001-01-01T00:00:00Z","name":"","rpo":0,"sourceAwsAccountId":"","sourceRegion":"","targetAwsAccountId":"","targetRegion":"","selectionRules":[{"ruleType":"allOf","rules":[{"ruleType":"tag","name":"env","value":"prod"},{"ruleType":"not","rule":{"ruleType":"anyOf","rules":[{"ruleType":"tag","name":"tier","value":"scratch"},{"ruleType":"arn","arns":["arn:a"]}]}}]}]}`
+	if string(bytes) != expected {
+		t.Fatalf("%s != %s", bytes, expected)
+	}
+
+	var decoded App
+	err = json.Unmarshal(bytes, &decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(orig.SelectionRules, decoded.SelectionRules) {
+		t.Fatalf("%#v != %#v", orig.SelectionRules, decoded.SelectionRules)
+	}
+}
+
+func TestCompositeRulesMatch(t *testing.T) {
+	// Tagged env=prod but not tagged tier=scratch
+	rule := NewAllOfRule([]SelectionRule{
+		NewTagRule("env", "prod"),
+		NewNotRule(NewTagRule("tier", "scratch")),
+	})
+
+	cases := []struct {
+		tags  map[string]string
+		match bool
+	}{
+		{map[string]string{"env": "prod"}, true},
+		{map[string]string{"env": "prod", "tier": "web"}, true},
+		{map[string]string{"env": "prod", "tier": "scratch"}, false},
+		{map[string]string{"env": "dev"}, false},
+	}
+	for _, tc := range cases {
+		if rule.Matches(StagedResource{Tags: tc.tags}) != tc.match {
+			t.Errorf("%v: expected match=%v", tc.tags, tc.match)
+		}
+	}
+
+	anyOf := NewAnyOfRule([]SelectionRule{NewArnRule([]string{"arn:a"}), NewTagRule("env", "prod")})
+	if !anyOf.Matches(StagedResource{ARN: "arn:a"}) || anyOf.Matches(StagedResource{ARN: "arn:b"}) {
+		t.Errorf("unexpected AnyOfRule result")
+	}
+}
+
+func TestNestedUnknownRules(t *testing.T) {
+	in := `{"selectionRules":[{"ruleType":"not","rule":{"ruleType":"future"}}]}`
+	var app App
+	err := json.Unmarshal([]byte(in), &app)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(app.UnknownSelectionRuleTypes(), []string{"future"}) {
+		t.Fatalf("nested unknown rule was not reported")
+	}
+	_, err = app.SelectResources([]StagedResource{{ARN: "arn:a"}})
+	if err == nil {
+		t.Fatalf("expected an UnevaluableRulesError")
+	}
+}
+
+func TestEmptyCompositeRules(t *testing.T) {
+	r := StagedResource{ARN: "arn:a"}
+	if NewAllOfRule(nil).Matches(r) {
+		t.Errorf("empty AllOfRule matched")
+	}
+	if NewAnyOfRule(nil).Matches(r) {
+		t.Errorf("empty AnyOfRule matched")
+	}
+}
+
+func TestNullCompositeRules(t *testing.T) {
+	for _, in := range []string{
+		`{"selectionRules":[{"ruleType":"allOf","rules":[null]}]}`,
+		`{"selectionRules":[{"ruleType":"anyOf","rules":[{"ruleType":"arn","arns":["arn:a"]},null]}]}`,
+		`{"selectionRules":[{"ruleType":"not","rule":null}]}`,
+		`{"selectionRules":[{"ruleType":"not"}]}`,
+	} {
+		var app App
+		if err := json.Unmarshal([]byte(in), &app); err == nil {
+			t.Errorf("%s: expected an error", in)
+		}
+	}
+}
+
+func TestCompositeRulesWithNilRules(t *testing.T) {
+	// Nil rules are reported by App.Validate, and never match
+	rules := []SelectionRule{
+		NewAllOfRule([]SelectionRule{nil}),
+		NewAnyOfRule([]SelectionRule{NewArnRule([]string{"arn:b"}), nil}),
+		NewNotRule(nil),
+	}
+	for _, r := range rules {
+		if r.(ResourceMatcher).Matches(StagedResource{ARN: "arn:a"}) {
+			t.Errorf("%#v matched", r)
+		}
+	}
+}
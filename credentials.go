@@ -0,0 +1,367 @@
+package arpio
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// DefaultProfile is the credentials file profile used when none is specified.
+const DefaultProfile = "default"
+
+// ErrNoCredentials is returned (possibly wrapped) by a CredentialsProvider
+// that has no credentials to offer.  ChainCredentialsProvider moves on to
+// the next provider when it sees this error.
+var ErrNoCredentials = errors.New("no Arpio credentials found")
+
+// Credentials contains the information needed to connect to the Arpio API.
+type Credentials struct {
+	APIURL       string
+	APIKeyID     string
+	APIKeySecret string
+	AccountID    string
+}
+
+// missing lists the required fields that are not set.  APIURL is optional
+// and defaults to ArpioURL.
+func (c Credentials) missing() (names []string) {
+	if c.APIKeyID == "" {
+		names = append(names, "API key ID")
+	}
+	if c.APIKeySecret == "" {
+		names = append(names, "API key secret")
+	}
+	if c.AccountID == "" {
+		names = append(names, "account ID")
+	}
+	return names
+}
+
+// CredentialsProvider supplies Credentials.
+type CredentialsProvider interface {
+	Retrieve() (Credentials, error)
+}
+
+// StaticCredentialsProvider supplies credentials specified in code.
+type StaticCredentialsProvider struct {
+	Credentials Credentials
+}
+
+// Retrieve returns the static credentials.
+func (p StaticCredentialsProvider) Retrieve() (Credentials, error) {
+	if missing := p.Credentials.missing(); len(missing) > 0 {
+		return Credentials{}, fmt.Errorf("static credentials are missing: %s",
+			strings.Join(missing, ", "))
+	}
+	return p.Credentials, nil
+}
+
+// EnvCredentialsProvider supplies credentials from the ARPIO_API_URL,
+// ARPIO_API_KEY_ID, ARPIO_API_KEY_SECRET and ARPIO_ACCOUNT_ID environment
+// variables.
+type EnvCredentialsProvider struct{}
+
+// Retrieve reads the credentials from the environment.  ErrNoCredentials is
+// returned if none of the key ID, secret and account ID variables are set
+// (ARPIO_API_URL alone is not credentials); an error naming the missing
+// variables is returned if only some of them are.
+func (p EnvCredentialsProvider) Retrieve() (Credentials, error) {
+	creds := Credentials{
+		APIURL:       os.Getenv(ArpioAPIURL),
+		APIKeyID:     os.Getenv(ArpioAPIKeyID),
+		APIKeySecret: os.Getenv(ArpioAPIKeySecret),
+		AccountID:    os.Getenv(ArpioAccountID),
+	}
+	if creds.APIKeyID == "" && creds.APIKeySecret == "" && creds.AccountID == "" {
+		return creds, fmt.Errorf("%w in the environment", ErrNoCredentials)
+	}
+
+	var missing []string
+	for name, v := range map[string]string{
+		ArpioAPIKeyID:     creds.APIKeyID,
+		ArpioAPIKeySecret: creds.APIKeySecret,
+		ArpioAccountID:    creds.AccountID,
+	} {
+		if v == "" {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return Credentials{}, fmt.Errorf("incomplete Arpio credentials in the "+
+			"environment; set %s", strings.Join(missing, ", "))
+	}
+	return creds, nil
+}
+
+// FileCredentialsProvider supplies credentials from a profile in an INI
+// credentials file:
+//
+//	[default]
+//	api_key_id = ...
+//	api_key_secret = ...
+//	account_id = ...
+//	api_url = https://api.arpio.io/api
+//
+// or in a YAML one:
+//
+//	default:
+//	  api_key_id: ...
+//	  api_key_secret: ...
+//	  account_id: ...
+//	  api_url: https://api.arpio.io/api
+//
+// api_url is optional.  Files whose first line, ignoring blank lines and
+// comments, is a "[section]" header are read as INI, and others as YAML.
+type FileCredentialsProvider struct {
+	// Filename is the path of the credentials file.  If empty, the
+	// ARPIO_SHARED_CREDENTIALS_FILE environment variable is used, then
+	// ~/.arpio/credentials.
+	Filename string
+
+	// Profile is the section of the file to read.  If empty, the
+	// ARPIO_PROFILE environment variable is used, then DefaultProfile.
+	Profile string
+}
+
+// Retrieve reads the credentials from the profile.  If the profile is the
+// implicit DefaultProfile, ErrNoCredentials is returned if the file or the
+// profile do not exist.  If the profile was named by Profile or
+// ARPIO_PROFILE, their absence is an error that doesn't wrap
+// ErrNoCredentials, so a mistyped profile name can't fall back to other
+// credentials.
+func (p FileCredentialsProvider) Retrieve() (Credentials, error) {
+	filename, err := p.filename()
+	if err != nil {
+		return Credentials{}, err
+	}
+	profile := p.Profile
+	if profile == "" {
+		profile = os.Getenv(ArpioProfile)
+	}
+	named := profile != ""
+	if !named {
+		profile = DefaultProfile
+	}
+
+	f, err := os.Open(filename)
+	if os.IsNotExist(err) {
+		if named {
+			return Credentials{}, fmt.Errorf("cannot read Arpio profile %q: %s does not exist",
+				profile, filename)
+		}
+		return Credentials{}, fmt.Errorf("%w: %s does not exist", ErrNoCredentials, filename)
+	}
+	if err != nil {
+		return Credentials{}, err
+	}
+	defer f.Close()
+
+	sections, err := parseCredentialsFile(f)
+	if err != nil {
+		return Credentials{}, fmt.Errorf("error reading %s: %w", filename, err)
+	}
+	values, ok := sections[profile]
+	if !ok && named {
+		return Credentials{}, fmt.Errorf("Arpio profile %q is not in %s", profile, filename)
+	}
+	if !ok {
+		return Credentials{}, fmt.Errorf("%w: profile %q is not in %s",
+			ErrNoCredentials, profile, filename)
+	}
+
+	creds := Credentials{
+		APIURL:       values["api_url"],
+		APIKeyID:     values["api_key_id"],
+		APIKeySecret: values["api_key_secret"],
+		AccountID:    values["account_id"],
+	}
+	if missing := creds.missing(); len(missing) > 0 {
+		return Credentials{}, fmt.Errorf("profile %q in %s is missing: %s",
+			profile, filename, strings.Join(missing, ", "))
+	}
+	return creds, nil
+}
+
+func (p FileCredentialsProvider) filename() (string, error) {
+	if p.Filename != "" {
+		return p.Filename, nil
+	}
+	if f := os.Getenv(ArpioSharedCredentialsFile); f != "" {
+		return f, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrNoCredentials, err)
+	}
+	return filepath.Join(home, ".arpio", "credentials"), nil
+}
+
+// ChainCredentialsProvider tries each of its providers in order and returns
+// the credentials from the first one that has them.  Errors other than
+// ErrNoCredentials stop the search.
+type ChainCredentialsProvider struct {
+	Providers []CredentialsProvider
+}
+
+// Retrieve returns the first credentials found.
+func (p ChainCredentialsProvider) Retrieve() (Credentials, error) {
+	var reasons []string
+	for _, provider := range p.Providers {
+		creds, err := provider.Retrieve()
+		if err == nil {
+			return creds, nil
+		}
+		if !errors.Is(err, ErrNoCredentials) {
+			return Credentials{}, err
+		}
+		reasons = append(reasons, err.Error())
+	}
+	if len(reasons) == 0 {
+		return Credentials{}, ErrNoCredentials
+	}
+	return Credentials{}, fmt.Errorf("%w (%s)", ErrNoCredentials,
+		strings.Join(reasons, "; "))
+}
+
+// DefaultCredentialsChain returns the provider chain NewClientFromEnvironment
+// uses.  When no profile is named, environment variables are checked before
+// the credentials file.  When a profile is named, by profile or by
+// ARPIO_PROFILE, the credentials file is checked first, so the named profile
+// wins over the environment.
+func DefaultCredentialsChain(profile string) ChainCredentialsProvider {
+	env := EnvCredentialsProvider{}
+	file := FileCredentialsProvider{Profile: profile}
+	if profile != "" || os.Getenv(ArpioProfile) != "" {
+		return ChainCredentialsProvider{Providers: []CredentialsProvider{file, env}}
+	}
+	return ChainCredentialsProvider{Providers: []CredentialsProvider{env, file}}
+}
+
+// NewClientFromEnvironment creates a Client using credentials from the
+// DefaultCredentialsChain for the specified profile (which may be empty).
+// If the credentials don't specify an API URL, ArpioURL is used.
+func NewClientFromEnvironment(profile string, opts ...Option) (*Client, error) {
+	return NewClientFromProvider(DefaultCredentialsChain(profile), opts...)
+}
+
+// NewClientFromProvider creates a Client using credentials from provider.
+// If the credentials don't specify an API URL, ArpioURL is used.
+func NewClientFromProvider(provider CredentialsProvider, opts ...Option) (*Client, error) {
+	creds, err := provider.Retrieve()
+	if err != nil {
+		return nil, err
+	}
+	apiURL := creds.APIURL
+	if apiURL == "" {
+		apiURL = ArpioURL
+	}
+	return NewClient(apiURL, creds.APIKeyID, creds.APIKeySecret, creds.AccountID, opts...)
+}
+
+// parseCredentialsFile parses an INI or YAML credentials file into a map of
+// profile name to key/value pairs.
+func parseCredentialsFile(r io.Reader) (map[string]map[string]string, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if isINI(b) {
+		return parseINI(bytes.NewReader(b))
+	}
+	return parseYAMLProfiles(b)
+}
+
+// isINI reports whether the first line of b that isn't blank or a comment
+// is an INI section header.
+func isINI(b []byte) bool {
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		return strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]")
+	}
+	return false
+}
+
+// parseYAMLProfiles parses a YAML document mapping profile names to maps of
+// key/value pairs.  Numeric values, such as unquoted account IDs, are kept
+// as written.
+func parseYAMLProfiles(b []byte) (map[string]map[string]string, error) {
+	j, err := yaml.YAMLToJSON(b)
+	if err != nil {
+		return nil, err
+	}
+	var raw map[string]map[string]interface{}
+	d := json.NewDecoder(bytes.NewReader(j))
+	d.UseNumber()
+	if err := d.Decode(&raw); err != nil {
+		return nil, fmt.Errorf("expected a map of profiles: %w", err)
+	}
+
+	sections := map[string]map[string]string{}
+	for name, values := range raw {
+		section := map[string]string{}
+		for k, v := range values {
+			switch t := v.(type) {
+			case string:
+				section[k] = t
+			case json.Number:
+				section[k] = t.String()
+			default:
+				return nil, fmt.Errorf("profile %q: %s is not a string", name, k)
+			}
+		}
+		sections[name] = section
+	}
+	return sections, nil
+}
+
+// parseINI parses a simple INI document into a map of section name to
+// key/value pairs.  Lines starting with '#' or ';' are comments.
+func parseINI(r io.Reader) (map[string]map[string]string, error) {
+	sections := map[string]map[string]string{}
+	var current map[string]string
+
+	scanner := bufio.NewScanner(r)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("line %d: unterminated section header", lineNum)
+			}
+			name := strings.TrimSpace(line[1 : len(line)-1])
+			current = sections[name]
+			if current == nil {
+				current = map[string]string{}
+				sections[name] = current
+			}
+			continue
+		}
+		i := strings.Index(line, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("line %d: expected key = value", lineNum)
+		}
+		if current == nil {
+			return nil, fmt.Errorf("line %d: key outside of a section", lineNum)
+		}
+		key := strings.TrimSpace(line[:i])
+		current[key] = strings.TrimSpace(line[i+1:])
+	}
+	return sections, scanner.Err()
+}
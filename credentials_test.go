@@ -0,0 +1,256 @@
+package arpio
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testCredentialsFile = `
+# Arpio credentials
+[default]
+api_key_id = default-id
+api_key_secret = default-secret
+account_id = default-account
+
+[staging]
+api_url = https://staging.example.com/api
+api_key_id = staging-id
+api_key_secret = staging-secret
+account_id = staging-account
+`
+
+// setEnv sets environment variables for the duration of a test and returns
+// a function that restores their previous values.
+func setEnv(vars map[string]string) func() {
+	prev := map[string]*string{}
+	for k, v := range vars {
+		if old, ok := os.LookupEnv(k); ok {
+			prev[k] = &old
+		} else {
+			prev[k] = nil
+		}
+		if v == "" {
+			_ = os.Unsetenv(k)
+		} else {
+			_ = os.Setenv(k, v)
+		}
+	}
+	return func() {
+		for k, v := range prev {
+			if v == nil {
+				_ = os.Unsetenv(k)
+			} else {
+				_ = os.Setenv(k, *v)
+			}
+		}
+	}
+}
+
+func writeTestCredentials(t *testing.T) (filename string, cleanup func()) {
+	dir, err := ioutil.TempDir("", "arpio-credentials")
+	if err != nil {
+		t.Fatal(err)
+	}
+	filename = filepath.Join(dir, "credentials")
+	err = ioutil.WriteFile(filename, []byte(testCredentialsFile), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return filename, func() { _ = os.RemoveAll(dir) }
+}
+
+func TestFileCredentialsProvider(t *testing.T) {
+	filename, cleanup := writeTestCredentials(t)
+	defer cleanup()
+	defer setEnv(map[string]string{ArpioProfile: ""})()
+
+	creds, err := FileCredentialsProvider{Filename: filename}.Retrieve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if creds.APIKeyID != "default-id" || creds.APIURL != "" {
+		t.Fatalf("unexpected credentials %+v", creds)
+	}
+
+	creds, err = FileCredentialsProvider{Filename: filename, Profile: "staging"}.Retrieve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Credentials{
+		APIURL:       "https://staging.example.com/api",
+		APIKeyID:     "staging-id",
+		APIKeySecret: "staging-secret",
+		AccountID:    "staging-account",
+	}
+	if creds != expected {
+		t.Fatalf("%+v != %+v", creds, expected)
+	}
+
+	// A missing named profile is an error, not an absence of credentials
+	_, err = FileCredentialsProvider{Filename: filename, Profile: "nope"}.Retrieve()
+	if err == nil || errors.Is(err, ErrNoCredentials) {
+		t.Fatalf("expected a missing profile error, got %v", err)
+	}
+	missing := filepath.Join(filepath.Dir(filename), "missing")
+	_, err = FileCredentialsProvider{Filename: missing, Profile: "staging"}.Retrieve()
+	if err == nil || errors.Is(err, ErrNoCredentials) {
+		t.Fatalf("expected a missing file error, got %v", err)
+	}
+
+	// The implicit default profile may be absent
+	_, err = FileCredentialsProvider{Filename: missing}.Retrieve()
+	if !errors.Is(err, ErrNoCredentials) {
+		t.Fatalf("expected ErrNoCredentials, got %v", err)
+	}
+}
+
+func TestFileCredentialsProviderYAML(t *testing.T) {
+	dir, err := ioutil.TempDir("", "arpio-credentials")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "credentials")
+	err = ioutil.WriteFile(filename, []byte(`
+# Arpio credentials
+default:
+  api_key_id: default-id
+  api_key_secret: default-secret
+  account_id: 123456789012
+staging:
+  api_url: https://staging.example.com/api
+  api_key_id: staging-id
+  api_key_secret: staging-secret
+  account_id: staging-account
+`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer setEnv(map[string]string{ArpioProfile: ""})()
+
+	creds, err := FileCredentialsProvider{Filename: filename}.Retrieve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if creds.APIKeyID != "default-id" || creds.AccountID != "123456789012" {
+		t.Fatalf("unexpected credentials %+v", creds)
+	}
+
+	creds, err = FileCredentialsProvider{Filename: filename, Profile: "staging"}.Retrieve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if creds.APIURL != "https://staging.example.com/api" || creds.AccountID != "staging-account" {
+		t.Fatalf("unexpected credentials %+v", creds)
+	}
+
+	err = ioutil.WriteFile(filename, []byte("default: [a, b]\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = (FileCredentialsProvider{Filename: filename}).Retrieve(); err == nil {
+		t.Fatal("expected an error for a malformed YAML file")
+	}
+}
+
+func TestEnvCredentialsProvider(t *testing.T) {
+	defer setEnv(map[string]string{
+		ArpioAPIURL:       "",
+		ArpioAPIKeyID:     "",
+		ArpioAPIKeySecret: "",
+		ArpioAccountID:    "",
+	})()
+
+	_, err := EnvCredentialsProvider{}.Retrieve()
+	if !errors.Is(err, ErrNoCredentials) {
+		t.Fatalf("expected ErrNoCredentials, got %v", err)
+	}
+
+	_ = os.Setenv(ArpioAPIKeyID, "env-id")
+	_, err = EnvCredentialsProvider{}.Retrieve()
+	if err == nil || errors.Is(err, ErrNoCredentials) {
+		t.Fatalf("expected an incomplete credentials error, got %v", err)
+	}
+
+	_ = os.Setenv(ArpioAPIKeySecret, "env-secret")
+	_ = os.Setenv(ArpioAccountID, "env-account")
+	creds, err := EnvCredentialsProvider{}.Retrieve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if creds.AccountID != "env-account" {
+		t.Fatalf("unexpected credentials %+v", creds)
+	}
+}
+
+func TestNewClientFromEnvironment(t *testing.T) {
+	filename, cleanup := writeTestCredentials(t)
+	defer cleanup()
+	defer setEnv(map[string]string{
+		ArpioSharedCredentialsFile: filename,
+		ArpioProfile:               "",
+		ArpioAPIURL:                "",
+		ArpioAPIKeyID:              "env-id",
+		ArpioAPIKeySecret:          "env-secret",
+		ArpioAccountID:             "env-account",
+	})()
+
+	// The environment wins when no profile is named
+	c, err := NewClientFromEnvironment("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.APIKeyID != "env-id" || c.APIUrl != ArpioURL {
+		t.Fatalf("unexpected client %+v", c)
+	}
+
+	// A named profile wins over the environment
+	c, err = NewClientFromEnvironment("staging")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.APIKeyID != "staging-id" || c.APIUrl != "https://staging.example.com/api" {
+		t.Fatalf("unexpected client %+v", c)
+	}
+}
+
+func TestNewClientFromEnvironmentFallback(t *testing.T) {
+	filename, cleanup := writeTestCredentials(t)
+	defer cleanup()
+	defer setEnv(map[string]string{
+		ArpioSharedCredentialsFile: filename,
+		ArpioProfile:               "",
+		ArpioAPIURL:                "https://env.example.com/api",
+		ArpioAPIKeyID:              "",
+		ArpioAPIKeySecret:          "",
+		ArpioAccountID:             "",
+	})()
+
+	// ARPIO_API_URL alone doesn't stop the chain before the file
+	c, err := NewClientFromEnvironment("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.APIKeyID != "default-id" {
+		t.Fatalf("unexpected client %+v", c)
+	}
+
+	// A mistyped profile doesn't fall back to other credentials
+	defer setEnv(map[string]string{
+		ArpioAPIKeyID:     "env-id",
+		ArpioAPIKeySecret: "env-secret",
+		ArpioAccountID:    "env-account",
+	})()
+	_, err = NewClientFromEnvironment("stagign")
+	if err == nil {
+		t.Fatal("expected an error for a missing profile")
+	}
+	defer setEnv(map[string]string{ArpioProfile: "stagign"})()
+	_, err = NewClientFromEnvironment("")
+	if err == nil {
+		t.Fatal("expected an error for a missing ARPIO_PROFILE profile")
+	}
+}
@@ -2,4 +2,11 @@ package arpio
 
 const (
 	ArpioTlsInsecureSkipVerify = "ARPIO_TLS_INSECURE_SKIP_VERIFY"
+
+	ArpioAPIURL                = "ARPIO_API_URL"
+	ArpioAPIKeyID              = "ARPIO_API_KEY_ID"
+	ArpioAPIKeySecret          = "ARPIO_API_KEY_SECRET"
+	ArpioAccountID             = "ARPIO_ACCOUNT_ID"
+	ArpioProfile               = "ARPIO_PROFILE"
+	ArpioSharedCredentialsFile = "ARPIO_SHARED_CREDENTIALS_FILE"
 )
@@ -0,0 +1,98 @@
+package arpio
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// requestIDHeaders are the response headers that may carry the identifier
+// the Arpio API assigned to a request, in order of preference.
+var requestIDHeaders = []string{
+	"X-Request-Id",
+	"X-Amzn-Requestid",
+}
+
+// APIError is returned by Client methods when the Arpio API responds with an
+// error status (>= 400).  Use errors.As to inspect it, or one of the IsXxx
+// helpers to check for common kinds of failure.
+type APIError struct {
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int
+
+	// Message is the error message from the API, if the response body was a
+	// standard ErrorResponse.
+	Message string
+
+	// AuthenticateURL is a URL the user can visit to re-authenticate, if the
+	// API included one.
+	AuthenticateURL string
+
+	// RequestID is the identifier the API assigned to the request, if any.
+	RequestID string
+
+	// Body is the raw response body, except that a body with sensitive
+	// values is redacted and re-encoded by the Client's Redactor.
+	Body []byte
+}
+
+// newAPIError builds an APIError from an error response.
+func newAPIError(status int, header http.Header, body []byte, errorResponse *ErrorResponse) *APIError {
+	e := &APIError{
+		StatusCode: status,
+		Body:       body,
+	}
+	if errorResponse != nil {
+		e.Message = errorResponse.Message
+		e.AuthenticateURL = errorResponse.AuthenticateURL
+	}
+	e.RequestID = requestIDFromHeader(header)
+	return e
+}
+
+// requestIDFromHeader finds the request ID in a response header.
+func requestIDFromHeader(header http.Header) string {
+	for _, h := range requestIDHeaders {
+		if id := header.Get(h); id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
+func (e *APIError) Error() string {
+	if e.Message != "" {
+		return e.Message
+	}
+	return fmt.Sprintf("Arpio API error: %s", e.Body)
+}
+
+// IsNotFound reports whether err is an APIError with a 404 status.
+func IsNotFound(err error) bool {
+	return hasStatus(err, http.StatusNotFound)
+}
+
+// IsUnauthorized reports whether err is an APIError with a 401 or 403
+// status.  The error's AuthenticateURL may tell the user how to
+// re-authenticate.
+func IsUnauthorized(err error) bool {
+	return hasStatus(err, http.StatusUnauthorized, http.StatusForbidden)
+}
+
+// IsConflict reports whether err is an APIError with a 409 status.
+func IsConflict(err error) bool {
+	return hasStatus(err, http.StatusConflict)
+}
+
+// IsRateLimited reports whether err is an APIError with a 429 status.
+func IsRateLimited(err error) bool {
+	return hasStatus(err, http.StatusTooManyRequests)
+}
+
+func hasStatus(err error, statuses ...int) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return SliceContainsInt(apiErr.StatusCode, statuses)
+}
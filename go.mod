@@ -1,3 +1,5 @@
 module github.com/arpio/arpio-client-go
 
 go 1.13
+
+require sigs.k8s.io/yaml v1.2.0
@@ -0,0 +1,136 @@
+package arpio
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// LogLevel is the severity of a log entry.
+type LogLevel int
+
+// Enumeration of log levels, from most to least verbose.
+const (
+	LevelTrace LogLevel = iota
+	LevelDebug
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+func (l LogLevel) String() string {
+	switch l {
+	case LevelTrace:
+		return "TRACE"
+	case LevelDebug:
+		return "DEBUG"
+	case LevelInfo:
+		return "INFO"
+	case LevelWarn:
+		return "WARN"
+	case LevelError:
+		return "ERROR"
+	default:
+		return fmt.Sprintf("LEVEL(%d)", int(l))
+	}
+}
+
+// Keys of the fields a Client attaches to its log entries.
+const (
+	LogFieldMethod    = "method"
+	LogFieldPath      = "path"
+	LogFieldStatus    = "status"
+	LogFieldDuration  = "duration"
+	LogFieldRequestID = "request_id"
+	LogFieldAttempt   = "attempt"
+	LogFieldDelay     = "delay"
+	LogFieldHeader    = "header"
+	LogFieldBody      = "body"
+	LogFieldError     = "error"
+)
+
+// Logger receives a Client's log entries.  keyvals holds alternating keys
+// (strings) and values, like log/slog.  Implementations must be safe for
+// concurrent use.
+type Logger interface {
+	Log(level LogLevel, msg string, keyvals ...interface{})
+}
+
+// LoggerFunc adapts a function to the Logger interface.
+type LoggerFunc func(level LogLevel, msg string, keyvals ...interface{})
+
+// Log calls f.
+func (f LoggerFunc) Log(level LogLevel, msg string, keyvals ...interface{}) {
+	f(level, msg, keyvals...)
+}
+
+// NopLogger discards all log entries.  It is the default Logger of a Client.
+type NopLogger struct{}
+
+// Log does nothing.
+func (NopLogger) Log(level LogLevel, msg string, keyvals ...interface{}) {}
+
+// SlogLogger is the subset of the *slog.Logger methods NewSlogLogger needs.
+// Other structured loggers with the same method set work too.
+type SlogLogger interface {
+	Debug(msg string, args ...interface{})
+	Info(msg string, args ...interface{})
+	Warn(msg string, args ...interface{})
+	Error(msg string, args ...interface{})
+}
+
+// NewSlogLogger creates a Logger that writes to a log/slog-style logger.
+// TRACE entries are written at the debug level.
+func NewSlogLogger(l SlogLogger) Logger {
+	return LoggerFunc(func(level LogLevel, msg string, keyvals ...interface{}) {
+		switch {
+		case level <= LevelDebug:
+			l.Debug(msg, keyvals...)
+		case level == LevelInfo:
+			l.Info(msg, keyvals...)
+		case level == LevelWarn:
+			l.Warn(msg, keyvals...)
+		default:
+			l.Error(msg, keyvals...)
+		}
+	})
+}
+
+// NewStdLogger creates a Logger that writes lines like
+//
+//	[DEBUG] Arpio API request method=GET path=/accounts/... status=200
+//
+// to l (or the standard logger if l is nil), dropping entries below
+// minLevel.  The bracketed level prefix is the format Terraform and similar
+// tools recognize.
+func NewStdLogger(l *log.Logger, minLevel LogLevel) Logger {
+	return LoggerFunc(func(level LogLevel, msg string, keyvals ...interface{}) {
+		if level < minLevel {
+			return
+		}
+		line := formatLogLine(level, msg, keyvals)
+		if l != nil {
+			_ = l.Output(2, line)
+		} else {
+			_ = log.Output(2, line)
+		}
+	})
+}
+
+// formatLogLine renders an entry as "[LEVEL] msg key=value ...".
+func formatLogLine(level LogLevel, msg string, keyvals []interface{}) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[%s] %s", level, msg)
+	for i := 0; i < len(keyvals); i += 2 {
+		var v interface{} = "(MISSING)"
+		if i+1 < len(keyvals) {
+			v = keyvals[i+1]
+		}
+		s := fmt.Sprint(v)
+		if strings.ContainsAny(s, " \t\n\"=") {
+			s = fmt.Sprintf("%q", s)
+		}
+		fmt.Fprintf(&b, " %v=%s", keyvals[i], s)
+	}
+	return b.String()
+}
@@ -0,0 +1,80 @@
+package arpio
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestStdLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewStdLogger(log.New(&buf, "", 0), LevelDebug)
+
+	logger.Log(LevelTrace, "hidden")
+	logger.Log(LevelWarn, "Arpio API request", LogFieldMethod, "GET", LogFieldError, "bad thing")
+
+	expected := "[WARN] Arpio API request method=GET error=\"bad thing\"\n"
+	if buf.String() != expected {
+		t.Fatalf("%q != %q", buf.String(), expected)
+	}
+}
+
+type testSlogLogger struct {
+	lines []string
+}
+
+func (l *testSlogLogger) record(level, msg string, args []interface{}) {
+	l.lines = append(l.lines, fmt.Sprintf("%s %s %v", level, msg, args))
+}
+
+func (l *testSlogLogger) Debug(msg string, args ...interface{}) { l.record("debug", msg, args) }
+func (l *testSlogLogger) Info(msg string, args ...interface{})  { l.record("info", msg, args) }
+func (l *testSlogLogger) Warn(msg string, args ...interface{})  { l.record("warn", msg, args) }
+func (l *testSlogLogger) Error(msg string, args ...interface{}) { l.record("error", msg, args) }
+
+func TestSlogLogger(t *testing.T) {
+	sl := &testSlogLogger{}
+	logger := NewSlogLogger(sl)
+
+	logger.Log(LevelTrace, "a", "k", 1)
+	logger.Log(LevelError, "b")
+
+	expected := []string{"debug a [k 1]", "error b []"}
+	if strings.Join(sl.lines, "|") != strings.Join(expected, "|") {
+		t.Fatalf("%v != %v", sl.lines, expected)
+	}
+}
+
+func TestClientLogsRequests(t *testing.T) {
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", "req-1")
+		_, _ = w.Write([]byte("[]"))
+	})
+	defer server.Close()
+
+	var mu sync.Mutex
+	fields := map[string]interface{}{}
+	c.Logger = LoggerFunc(func(level LogLevel, msg string, keyvals ...interface{}) {
+		mu.Lock()
+		defer mu.Unlock()
+		for i := 0; i+1 < len(keyvals); i += 2 {
+			fields[keyvals[i].(string)] = keyvals[i+1]
+		}
+	})
+
+	_, err := c.ListApps()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fields[LogFieldMethod] != "GET" ||
+		fields[LogFieldPath] != "/accounts/acct/applications" ||
+		fields[LogFieldStatus] != http.StatusOK ||
+		fields[LogFieldRequestID] != "req-1" ||
+		fields[LogFieldDuration] == nil {
+		t.Fatalf("unexpected fields %v", fields)
+	}
+}
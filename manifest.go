@@ -0,0 +1,104 @@
+package arpio
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Manifest is a document listing the desired apps of an account, for
+// Reconciler.  Manifests are written in YAML or JSON using the App field
+// names of the Arpio API:
+//
+//	apps:
+//	  - name: web
+//	    type: standard
+//	    rpo: 3600
+//	    sourceAwsAccountId: "111111111111"
+//	    sourceRegion: us-east-1
+//	    targetAwsAccountId: "222222222222"
+//	    targetRegion: us-west-2
+//	    selectionRules: tag:env=prod and not tag:tier=scratch
+//
+// selectionRules may be a list of rules in their JSON form, or a string in
+// the expression language read by ParseSelectionRules.
+type Manifest struct {
+	Apps []App `json:"apps"`
+}
+
+func (m *Manifest) UnmarshalJSON(b []byte) error {
+	var raw struct {
+		Apps []map[string]json.RawMessage `json:"apps"`
+	}
+	err := json.Unmarshal(b, &raw)
+	if err != nil {
+		return err
+	}
+
+	m.Apps = []App{}
+	for i, fields := range raw.Apps {
+		// Convert selection rule expressions to their JSON form
+		if expr := fields["selectionRules"]; len(expr) > 0 && expr[0] == '"' {
+			var s string
+			if err := json.Unmarshal(expr, &s); err != nil {
+				return err
+			}
+			rules, err := ParseSelectionRules(s)
+			if err != nil {
+				return fmt.Errorf("apps[%d].selectionRules: %w", i, err)
+			}
+			rawRules, err := marshalSelectionRules(rules)
+			if err != nil {
+				return err
+			}
+			if fields["selectionRules"], err = json.Marshal(rawRules); err != nil {
+				return err
+			}
+		}
+
+		b, err := json.Marshal(fields)
+		if err != nil {
+			return err
+		}
+		var a App
+		if err := json.Unmarshal(b, &a); err != nil {
+			return fmt.Errorf("apps[%d]: %w", i, err)
+		}
+		m.Apps = append(m.Apps, a)
+	}
+	return nil
+}
+
+// ParseManifest parses a YAML or JSON Manifest.
+func ParseManifest(b []byte) (m Manifest, err error) {
+	j, err := yaml.YAMLToJSON(b)
+	if err != nil {
+		return m, err
+	}
+	if bytes.Equal(bytes.TrimSpace(j), []byte("null")) {
+		return Manifest{Apps: []App{}}, nil
+	}
+	err = json.Unmarshal(j, &m)
+	return m, err
+}
+
+// LoadManifests reads and parses the named Manifest files, and returns the
+// apps from all of them, in order.
+func LoadManifests(filenames ...string) (apps []App, err error) {
+	apps = []App{}
+	for _, filename := range filenames {
+		b, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return nil, err
+		}
+		m, err := ParseManifest(b)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filename, err)
+		}
+		apps = append(apps, m.Apps...)
+	}
+	return apps, nil
+}
@@ -0,0 +1,109 @@
+package arpio
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseManifest(t *testing.T) {
+	in := `
+apps:
+  - name: web
+    type: standard
+    rpo: 3600
+    notificationEmails: [ops@example.com]
+    sourceAwsAccountId: "111111111111"
+    sourceRegion: us-east-1
+    targetAwsAccountId: "222222222222"
+    targetRegion: us-west-2
+    selectionRules: tag:env=prod and not tag:tier=scratch
+  - name: db
+    type: terraform
+    rpo: 60
+    sourceAwsAccountId: "111111111111"
+    sourceRegion: us-east-1
+    targetAwsAccountId: "222222222222"
+    targetRegion: us-west-2
+    selectionRules:
+      - ruleType: arn
+        arns: ["arn:aws:rds:us-east-1:111111111111:cluster:db"]
+`
+	m, err := ParseManifest([]byte(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Apps) != 2 {
+		t.Fatalf("%d != 2", len(m.Apps))
+	}
+
+	web := m.Apps[0]
+	if web.Name != "web" || web.RPO != 3600 || web.SourceAwsAccountID != "111111111111" {
+		t.Fatalf("unexpected app: %#v", web)
+	}
+	expectedRules := []SelectionRule{
+		NewAllOfRule([]SelectionRule{
+			NewTagRule("env", "prod"),
+			NewNotRule(NewTagRule("tier", "scratch")),
+		}),
+	}
+	if !reflect.DeepEqual(web.SelectionRules, expectedRules) {
+		t.Fatalf("%#v != %#v", web.SelectionRules, expectedRules)
+	}
+
+	db := m.Apps[1]
+	expectedRules = []SelectionRule{NewArnRule([]string{"arn:aws:rds:us-east-1:111111111111:cluster:db"})}
+	if !reflect.DeepEqual(db.SelectionRules, expectedRules) {
+		t.Fatalf("%#v != %#v", db.SelectionRules, expectedRules)
+	}
+}
+
+func TestParseManifestErrors(t *testing.T) {
+	cases := []string{
+		"apps: [",
+		"apps:\n  - name: web\n    selectionRules: tag:env=",
+		"apps:\n  - name: web\n    rpo: soon",
+	}
+	for _, in := range cases {
+		if _, err := ParseManifest([]byte(in)); err == nil {
+			t.Fatalf("%q: expected an error", in)
+		}
+	}
+}
+
+func TestLoadManifests(t *testing.T) {
+	dir, err := ioutil.TempDir("", "manifests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	yamlFile := filepath.Join(dir, "a.yaml")
+	err = ioutil.WriteFile(yamlFile, []byte("apps:\n  - name: a\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jsonFile := filepath.Join(dir, "b.json")
+	err = ioutil.WriteFile(jsonFile, []byte(`{"apps": [{"name": "b"}, {"name": "c"}]}`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	apps, err := LoadManifests(yamlFile, jsonFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, a := range apps {
+		names = append(names, a.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"a", "b", "c"}) {
+		t.Fatalf("%v != [a b c]", names)
+	}
+
+	if _, err := LoadManifests(filepath.Join(dir, "missing.yaml")); err == nil {
+		t.Fatal("expected an error for a missing file")
+	}
+}
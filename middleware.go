@@ -0,0 +1,159 @@
+package arpio
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// APIRequest is an Arpio API request as seen by middleware.
+type APIRequest struct {
+	// Method is the HTTP method.
+	Method string
+
+	// RelativeURL is the request path (and query) relative to the API URL.
+	RelativeURL string
+
+	// Body is the request body before it is marshaled to JSON, or nil.
+	Body interface{}
+
+	// Header holds extra headers to send with the request.
+	Header http.Header
+}
+
+// APIResponse is an Arpio API response as seen by middleware.
+type APIResponse struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// RoundTripFunc performs an API request.  When the API responds with an
+// error status, both the response and an *APIError are returned.
+type RoundTripFunc func(ctx context.Context, req *APIRequest) (*APIResponse, error)
+
+// Middleware wraps a RoundTripFunc to add behavior around API requests.
+// Middleware may modify the request, short-circuit it by not calling next,
+// or inspect and replace the result.
+type Middleware func(next RoundTripFunc) RoundTripFunc
+
+// LoggingMiddleware logs every request to logger (which may be nil) with
+// the method, path, status, duration and request ID, plus the request
+// headers and body, scrubbed by redactor, at the TRACE level.  Requests
+// pass through untouched if logger is nil or a NopLogger, so a silent
+// Client doesn't pay for redacting them.
+func LoggingMiddleware(logger Logger, redactor *Redactor) Middleware {
+	if _, ok := logger.(NopLogger); ok || logger == nil {
+		return func(next RoundTripFunc) RoundTripFunc {
+			return next
+		}
+	}
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(ctx context.Context, req *APIRequest) (*APIResponse, error) {
+			if len(req.Header) > 0 {
+				logger.Log(LevelTrace, "Arpio API request headers",
+					LogFieldMethod, req.Method,
+					LogFieldPath, req.RelativeURL,
+					LogFieldHeader, redactor.RedactHeader(req.Header))
+			}
+			if req.Body != nil {
+				if b, err := json.Marshal(req.Body); err == nil {
+					logger.Log(LevelTrace, "Arpio API request body",
+						LogFieldMethod, req.Method,
+						LogFieldPath, req.RelativeURL,
+						LogFieldBody, string(redactor.RedactJSON(b)))
+				}
+			}
+
+			start := time.Now()
+			resp, err := next(ctx, req)
+
+			var status int
+			var header http.Header
+			if resp != nil {
+				status = resp.StatusCode
+				header = resp.Header
+			}
+			keyvals := []interface{}{
+				LogFieldMethod, req.Method,
+				LogFieldPath, req.RelativeURL,
+				LogFieldStatus, status,
+				LogFieldDuration, time.Since(start),
+				LogFieldRequestID, requestIDFromHeader(header),
+			}
+			level := LevelDebug
+			if (err != nil && resp == nil) || status >= 500 {
+				level = LevelWarn
+			}
+			if err != nil {
+				keyvals = append(keyvals, LogFieldError, err)
+			}
+			logger.Log(level, "Arpio API request", keyvals...)
+
+			return resp, err
+		}
+	}
+}
+
+// RequestMetrics describes one completed API request.
+type RequestMetrics struct {
+	Method     string
+	Path       string
+	Class      EndpointClass
+	StatusCode int
+	Duration   time.Duration
+	Err        error
+}
+
+// MetricsRecorder receives RequestMetrics from MetricsMiddleware.
+// Implementations must be safe for concurrent use.
+type MetricsRecorder interface {
+	RecordRequest(m RequestMetrics)
+}
+
+// MetricsRecorderFunc adapts a function to the MetricsRecorder interface.
+type MetricsRecorderFunc func(m RequestMetrics)
+
+// RecordRequest calls f.
+func (f MetricsRecorderFunc) RecordRequest(m RequestMetrics) {
+	f(m)
+}
+
+// MetricsMiddleware reports the outcome and duration of every request to
+// recorder.  Class is included so recorders can aggregate without the
+// unbounded cardinality of Path.
+func MetricsMiddleware(recorder MetricsRecorder) Middleware {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(ctx context.Context, req *APIRequest) (*APIResponse, error) {
+			start := time.Now()
+			resp, err := next(ctx, req)
+
+			m := RequestMetrics{
+				Method:   req.Method,
+				Path:     req.RelativeURL,
+				Class:    endpointClassFor(req.RelativeURL),
+				Duration: time.Since(start),
+				Err:      err,
+			}
+			if resp != nil {
+				m.StatusCode = resp.StatusCode
+			}
+			recorder.RecordRequest(m)
+
+			return resp, err
+		}
+	}
+}
+
+// RateLimitMiddleware makes every request wait for limiter.
+func RateLimitMiddleware(limiter RateLimiter) Middleware {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(ctx context.Context, req *APIRequest) (*APIResponse, error) {
+			if err := limiter.Wait(ctx, endpointClassFor(req.RelativeURL)); err != nil {
+				return nil, err
+			}
+			return next(ctx, req)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package arpio
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestMiddlewareChain(t *testing.T) {
+	var gotHeader string
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		gotHeader = r.Header.Get("X-Audit")
+		_, _ = w.Write([]byte(`{"appId":"b"}`))
+	})
+	defer server.Close()
+
+	var order []string
+	var seenBody interface{}
+	var metrics []RequestMetrics
+	c.Middleware = []Middleware{
+		func(next RoundTripFunc) RoundTripFunc {
+			return func(ctx context.Context, req *APIRequest) (*APIResponse, error) {
+				order = append(order, "outer")
+				req.Header.Set("X-Audit", "yes")
+				seenBody = req.Body
+				return next(ctx, req)
+			}
+		},
+		func(next RoundTripFunc) RoundTripFunc {
+			return func(ctx context.Context, req *APIRequest) (*APIResponse, error) {
+				order = append(order, "inner")
+				return next(ctx, req)
+			}
+		},
+		MetricsMiddleware(MetricsRecorderFunc(func(m RequestMetrics) {
+			metrics = append(metrics, m)
+		})),
+	}
+
+	app := c.NewApp()
+	app.AppID = "b"
+	_, err := c.UpdateApp(app)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(order) != 2 || order[0] != "outer" || order[1] != "inner" {
+		t.Fatalf("unexpected middleware order %v", order)
+	}
+	if gotHeader != "yes" {
+		t.Fatalf("middleware header was not sent")
+	}
+	if _, ok := seenBody.(App); !ok {
+		t.Fatalf("middleware saw a %T body", seenBody)
+	}
+	if len(metrics) != 1 ||
+		metrics[0].Method != http.MethodPut ||
+		metrics[0].Class != AppsEndpointClass ||
+		metrics[0].StatusCode != http.StatusOK {
+		t.Fatalf("unexpected metrics %+v", metrics)
+	}
+}
+
+func TestMiddlewareFaultInjection(t *testing.T) {
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("request should not reach the server")
+	})
+	defer server.Close()
+
+	injected := errors.New("injected")
+	c.Middleware = []Middleware{
+		func(next RoundTripFunc) RoundTripFunc {
+			return func(ctx context.Context, req *APIRequest) (*APIResponse, error) {
+				return nil, injected
+			}
+		},
+	}
+
+	_, err := c.ListApps()
+	if err != injected {
+		t.Fatalf("expected injected error, got %v", err)
+	}
+}
+
+func TestLoggingMiddlewareRedactsHeaders(t *testing.T) {
+	var logged interface{}
+	logger := LoggerFunc(func(level LogLevel, msg string, keyvals ...interface{}) {
+		for i := 0; i+1 < len(keyvals); i += 2 {
+			if keyvals[i] == LogFieldHeader {
+				logged = keyvals[i+1]
+			}
+		}
+	})
+	rt := LoggingMiddleware(logger, DefaultRedactor())(func(ctx context.Context, req *APIRequest) (*APIResponse, error) {
+		return &APIResponse{StatusCode: http.StatusOK}, nil
+	})
+
+	header := http.Header{}
+	header.Set("Authorization", "Bearer secret")
+	header.Set("X-Trace", "t-1")
+	_, err := rt(context.Background(), &APIRequest{Method: "GET", RelativeURL: "/x", Header: header})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h, ok := logged.(http.Header)
+	if !ok || h.Get("Authorization") != RedactedValue || h.Get("X-Trace") != "t-1" {
+		t.Fatalf("unexpected logged header %v", logged)
+	}
+	if header.Get("Authorization") != "Bearer secret" {
+		t.Fatalf("the request header was modified")
+	}
+}
+
+// countingBody counts how often it is marshaled.
+type countingBody struct {
+	n *int
+}
+
+func (b countingBody) MarshalJSON() ([]byte, error) {
+	*b.n++
+	return []byte(`{}`), nil
+}
+
+func TestLoggingMiddlewareNopLogger(t *testing.T) {
+	for _, logger := range []Logger{nil, NopLogger{}} {
+		var marshaled int
+		rt := LoggingMiddleware(logger, DefaultRedactor())(func(ctx context.Context, req *APIRequest) (*APIResponse, error) {
+			return &APIResponse{StatusCode: http.StatusOK}, nil
+		})
+		_, err := rt(context.Background(), &APIRequest{Method: "POST", RelativeURL: "/x", Body: countingBody{&marshaled}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if marshaled != 0 {
+			t.Fatalf("%#v: the body was marshaled %d times", logger, marshaled)
+		}
+	}
+}
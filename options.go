@@ -0,0 +1,138 @@
+package arpio
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Option configures a Client created by NewClient.
+type Option func(c *Client) error
+
+// WithHTTPClient makes the Client send requests through hc instead of its
+// default HTTP client.  Options that adjust the HTTP client (WithTimeout,
+// WithTransport, WithTLSConfig) work on a copy, so hc itself is never
+// modified.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) error {
+		if hc == nil {
+			return fmt.Errorf("HTTP client must not be nil")
+		}
+		c.HTTPClient = hc
+		return nil
+	}
+}
+
+// WithTimeout sets the overall timeout of each HTTP request.  The default
+// is 60 seconds.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) error {
+		hc := *c.HTTPClient
+		hc.Timeout = timeout
+		c.HTTPClient = &hc
+		return nil
+	}
+}
+
+// WithTransport makes the Client's HTTP client use the specified transport.
+func WithTransport(rt http.RoundTripper) Option {
+	return func(c *Client) error {
+		if rt == nil {
+			return fmt.Errorf("transport must not be nil")
+		}
+		hc := *c.HTTPClient
+		hc.Transport = rt
+		c.HTTPClient = &hc
+		return nil
+	}
+}
+
+// WithTLSConfig sets the TLS configuration of the Client's transport,
+// overriding the ARPIO_TLS_INSECURE_SKIP_VERIFY environment variable.  The
+// transport must be an *http.Transport.
+func WithTLSConfig(cfg *tls.Config) Option {
+	return func(c *Client) error {
+		rt := c.HTTPClient.Transport
+		if rt == nil {
+			rt = http.DefaultTransport
+		}
+		tr, ok := rt.(*http.Transport)
+		if !ok {
+			return fmt.Errorf("cannot set the TLS config of a %T transport", rt)
+		}
+		tr = tr.Clone()
+		tr.TLSClientConfig = cfg
+		hc := *c.HTTPClient
+		hc.Transport = tr
+		c.HTTPClient = &hc
+		return nil
+	}
+}
+
+// WithUserAgentSuffix appends suffix to the User-Agent header of every
+// request, to identify the embedding application.
+func WithUserAgentSuffix(suffix string) Option {
+	return func(c *Client) error {
+		c.UserAgentSuffix = suffix
+		return nil
+	}
+}
+
+// WithLogger sends the Client's log entries to logger.  Clients log nothing
+// by default.
+func WithLogger(logger Logger) Option {
+	return func(c *Client) error {
+		c.Logger = logger
+		return nil
+	}
+}
+
+// WithRetryPolicy replaces the DefaultRetryPolicy.  Pass the zero
+// RetryPolicy to disable retries.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *Client) error {
+		c.RetryPolicy = policy
+		return nil
+	}
+}
+
+// WithRateLimiter limits the Client's request rate with limiter.
+func WithRateLimiter(limiter RateLimiter) Option {
+	return func(c *Client) error {
+		c.RateLimiter = limiter
+		return nil
+	}
+}
+
+// WithRateLimit limits the Client's request rate with a TokenBucket that
+// allows requestsPerSecond requests per second and bursts of up to burst
+// requests.  An error is returned if requestsPerSecond is not a positive,
+// finite number or burst is less than 1.
+func WithRateLimit(requestsPerSecond float64, burst int) Option {
+	return func(c *Client) error {
+		if err := validateRateLimit(requestsPerSecond, burst); err != nil {
+			return err
+		}
+		c.RateLimiter = NewTokenBucket(requestsPerSecond, burst)
+		return nil
+	}
+}
+
+// WithRedactor replaces the DefaultRedactor.  Pass &Redactor{} to disable
+// redaction.
+func WithRedactor(redactor *Redactor) Option {
+	return func(c *Client) error {
+		c.Redactor = redactor
+		return nil
+	}
+}
+
+// WithMiddleware appends middleware to the Client's chain.  Middleware added
+// first is outermost.
+func WithMiddleware(mw ...Middleware) Option {
+	return func(c *Client) error {
+		c.Middleware = append(c.Middleware, mw...)
+		return nil
+	}
+}
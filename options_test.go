@@ -0,0 +1,84 @@
+package arpio
+
+import (
+	"bytes"
+	"crypto/tls"
+	"log"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewClientOptions(t *testing.T) {
+	hc := &http.Client{Timeout: time.Second}
+	var buf bytes.Buffer
+	logger := NewStdLogger(log.New(&buf, "", 0), LevelTrace)
+
+	c, err := NewClient("https://example.com", "id", "secret", "acct",
+		WithHTTPClient(hc),
+		WithTimeout(5*time.Second),
+		WithTLSConfig(&tls.Config{ServerName: "example.com"}),
+		WithUserAgentSuffix("my-app/1.0"),
+		WithLogger(logger),
+		WithRetryPolicy(RetryPolicy{}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if hc.Timeout != time.Second || hc.Transport != nil {
+		t.Fatalf("the caller's HTTP client was modified")
+	}
+	if c.HTTPClient.Timeout != 5*time.Second {
+		t.Fatalf("%s != %s", c.HTTPClient.Timeout, 5*time.Second)
+	}
+	tr, ok := c.HTTPClient.Transport.(*http.Transport)
+	if !ok || tr.TLSClientConfig.ServerName != "example.com" {
+		t.Fatalf("TLS config was not applied")
+	}
+	if c.UserAgentSuffix != "my-app/1.0" || c.RetryPolicy.MaxAttempts != 0 {
+		t.Fatalf("options were not applied: %+v", c)
+	}
+
+	c.log(LevelDebug, "hello")
+	if buf.String() != "[DEBUG] hello\n" {
+		t.Fatalf("logger was not used")
+	}
+}
+
+func TestUserAgentSuffix(t *testing.T) {
+	var userAgent string
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.Header.Get("User-Agent")
+		_, _ = w.Write([]byte("[]"))
+	})
+	defer server.Close()
+	c.UserAgentSuffix = "my-app/1.0"
+
+	_, err := c.ListApps()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(userAgent, userAgentPrefix+"/") || !strings.HasSuffix(userAgent, " my-app/1.0") {
+		t.Fatalf("unexpected User-Agent %q", userAgent)
+	}
+}
+
+func TestWithTLSConfigRequiresHTTPTransport(t *testing.T) {
+	_, err := NewClient("https://example.com", "id", "secret", "acct",
+		WithTransport(roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			return nil, nil
+		})),
+		WithTLSConfig(&tls.Config{}),
+	)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+type roundTripperFunc func(r *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
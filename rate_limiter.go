@@ -0,0 +1,142 @@
+package arpio
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EndpointClass groups Arpio API endpoints so they can be rate limited
+// separately.
+type EndpointClass string
+
+// Enumeration of the endpoint classes the Client distinguishes.
+const (
+	AppsEndpointClass                   EndpointClass = "apps"
+	RecoveryPointsEndpointClass         EndpointClass = "recoveryPoints"
+	RecoveryPointResourcesEndpointClass EndpointClass = "recoveryPointResources"
+	OtherEndpointClass                  EndpointClass = "other"
+)
+
+// RateLimiter limits the rate of API requests a Client makes.  Wait blocks
+// until a request to an endpoint of the specified class may proceed, or
+// returns ctx's error if ctx is done first.  Implementations must be safe
+// for concurrent use.
+type RateLimiter interface {
+	Wait(ctx context.Context, class EndpointClass) error
+}
+
+// TokenBucket is a RateLimiter that allows requests at a steady rate with
+// bursts up to a fixed size, regardless of endpoint class.  Waiting callers
+// are admitted in the order they arrived.
+type TokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewTokenBucket creates a TokenBucket that allows requestsPerSecond
+// requests per second on average, and up to burst requests at once.  It
+// panics if requestsPerSecond is not a positive, finite number or burst is
+// less than 1.
+func NewTokenBucket(requestsPerSecond float64, burst int) *TokenBucket {
+	if err := validateRateLimit(requestsPerSecond, burst); err != nil {
+		panic("arpio: NewTokenBucket: " + err.Error())
+	}
+	return &TokenBucket{
+		rate:   requestsPerSecond,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// Wait reserves a token, then blocks until the reservation comes due.
+// Reservations are made in arrival order, so no caller can be starved by
+// others arriving later.
+func (b *TokenBucket) Wait(ctx context.Context, class EndpointClass) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+	b.tokens--
+	var wait time.Duration
+	if b.tokens < 0 {
+		wait = time.Duration(-b.tokens / b.rate * float64(time.Second))
+	}
+	b.mu.Unlock()
+
+	if wait <= 0 {
+		return nil
+	}
+	err := sleepContext(ctx, wait)
+	if err != nil {
+		// Give the reservation back so later callers don't wait for it
+		b.mu.Lock()
+		b.tokens++
+		b.mu.Unlock()
+	}
+	return err
+}
+
+// EndpointRateLimiter applies a separate RateLimiter to each endpoint class,
+// plus an optional Default limiter that every request must also pass.
+type EndpointRateLimiter struct {
+	Default RateLimiter
+	Classes map[EndpointClass]RateLimiter
+}
+
+// Wait waits for the class-specific limiter (if any), then for the Default
+// limiter (if any).
+func (l EndpointRateLimiter) Wait(ctx context.Context, class EndpointClass) error {
+	if cl, ok := l.Classes[class]; ok && cl != nil {
+		if err := cl.Wait(ctx, class); err != nil {
+			return err
+		}
+	}
+	if l.Default != nil {
+		return l.Default.Wait(ctx, class)
+	}
+	return nil
+}
+
+// endpointClassFor determines the EndpointClass of a relative API URL.
+func endpointClassFor(relativeURL string) EndpointClass {
+	path := relativeURL
+	if i := strings.Index(path, "?"); i >= 0 {
+		path = path[:i]
+	}
+	switch {
+	case strings.Contains(path, "/recoveryPoints/") && strings.HasSuffix(path, "/resources"):
+		return RecoveryPointResourcesEndpointClass
+	case strings.Contains(path, "/recoveryPoints"):
+		return RecoveryPointsEndpointClass
+	case strings.Contains(path, "/applications"):
+		return AppsEndpointClass
+	default:
+		return OtherEndpointClass
+	}
+}
+
+// validateRateLimit checks the parameters of a TokenBucket.
+func validateRateLimit(requestsPerSecond float64, burst int) error {
+	if !(requestsPerSecond > 0) || math.IsInf(requestsPerSecond, 0) {
+		return fmt.Errorf("requests per second must be a positive, finite number, not %v", requestsPerSecond)
+	}
+	if burst < 1 {
+		return fmt.Errorf("burst must be at least 1, not %d", burst)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package arpio
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTokenBucketBurstAndRate(t *testing.T) {
+	b := NewTokenBucket(100, 2)
+	ctx := context.Background()
+
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		if err := b.Wait(ctx, OtherEndpointClass); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Two requests fit in the burst, the other two wait 10ms each
+	if elapsed := time.Since(start); elapsed < 15*time.Millisecond {
+		t.Fatalf("requests were not limited (%s)", elapsed)
+	}
+}
+
+func TestTokenBucketConcurrent(t *testing.T) {
+	b := NewTokenBucket(1000, 1)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := b.Wait(ctx, OtherEndpointClass); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestTokenBucketContextCancel(t *testing.T) {
+	b := NewTokenBucket(0.001, 1)
+	_ = b.Wait(context.Background(), OtherEndpointClass)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := b.Wait(ctx, OtherEndpointClass)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+}
+
+func TestTokenBucketInvalid(t *testing.T) {
+	cases := []struct {
+		rate  float64
+		burst int
+	}{
+		{0, 1},
+		{-1, 1},
+		{math.NaN(), 1},
+		{math.Inf(1), 1},
+		{1, 0},
+	}
+	for _, c := range cases {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("NewTokenBucket(%v, %d) did not panic", c.rate, c.burst)
+				}
+			}()
+			NewTokenBucket(c.rate, c.burst)
+		}()
+
+		_, err := NewClient("http://localhost", "id", "secret", "acct", WithRateLimit(c.rate, c.burst))
+		if err == nil {
+			t.Errorf("WithRateLimit(%v, %d) did not return an error", c.rate, c.burst)
+		}
+	}
+
+	c, err := NewClient("http://localhost", "id", "secret", "acct", WithRateLimit(10, 5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c.RateLimiter.(*TokenBucket); !ok {
+		t.Fatalf("unexpected rate limiter: %#v", c.RateLimiter)
+	}
+}
+
+func TestEndpointClassFor(t *testing.T) {
+	cases := map[string]EndpointClass{
+		"/accounts/a/applications":                                 AppsEndpointClass,
+		"/accounts/a/applications/b":                               AppsEndpointClass,
+		"/accounts/a/syncPairs/1/r/2/r/recoveryPoints?x=y":         RecoveryPointsEndpointClass,
+		"/accounts/a/syncPairs/1/r/2/r/recoveryPoints/p":           RecoveryPointsEndpointClass,
+		"/accounts/a/syncPairs/1/r/2/r/recoveryPoints/p/resources": RecoveryPointResourcesEndpointClass,
+		"/accounts/a": OtherEndpointClass,
+	}
+	for u, expected := range cases {
+		if class := endpointClassFor(u); class != expected {
+			t.Errorf("%s: %s != %s", u, class, expected)
+		}
+	}
+}
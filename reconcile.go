@@ -0,0 +1,236 @@
+package arpio
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ReconcileAction is the change a Reconciler makes to an app.
+type ReconcileAction string
+
+// Enumeration of the changes a Reconciler makes.
+const (
+	ReconcileNone    ReconcileAction = "none"
+	ReconcileCreate  ReconcileAction = "create"
+	ReconcileUpdate  ReconcileAction = "update"
+	ReconcileReplace ReconcileAction = "replace"
+	ReconcileDelete  ReconcileAction = "delete"
+)
+
+// Reconciler makes the apps in the Client's account match a desired set of
+// apps, such as one loaded with LoadManifests.  Apps are identified by name,
+// as with GetAppByName.
+type Reconciler struct {
+	Client *Client
+
+	// Delete enables deleting apps that aren't in the desired set.
+	Delete bool
+
+	// DryRun computes the changes without making them.
+	DryRun bool
+}
+
+// ReconcileResult describes the change to one app.
+type ReconcileResult struct {
+	Action ReconcileAction
+	Name   string
+
+	// AppID is the ID of the existing app, or of the created app.
+	AppID string
+
+	// Diff is the difference between the existing and desired app, for
+	// updates and replacements.
+	Diff AppDiff
+
+	// Applied is true if the change was made.
+	Applied bool
+
+	// Err is the error that prevented the change from being made.
+	Err error
+}
+
+// ReconcileReport is the result of reconciling a set of apps.
+type ReconcileReport struct {
+	DryRun  bool
+	Results []ReconcileResult
+}
+
+// Reconcile compares the desired apps with the apps in the Client's account
+// and creates, updates and (if Delete is set) deletes apps to match.  Apps
+// whose changes would require replacement (see DiffApps) are reported with
+// an error rather than deleted and recreated, since that discards their
+// recovery points.
+//
+// The desired apps are validated (see App.Validate) before any changes are
+// made.  Failing changes don't stop the others from being made; their
+// errors are recorded in the report, and an error summarizing them is
+// returned.
+func (r Reconciler) Reconcile(ctx context.Context, desired []App) (*ReconcileReport, error) {
+	names := map[string]bool{}
+	for _, a := range desired {
+		if names[a.Name] {
+			return nil, fmt.Errorf("more than one desired app is named %q", a.Name)
+		}
+		names[a.Name] = true
+		if err := a.Validate(); err != nil {
+			return nil, fmt.Errorf("app %q: %w", a.Name, err)
+		}
+	}
+
+	current, err := r.Client.ListAppsWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byName := map[string][]App{}
+	for _, a := range current {
+		byName[a.Name] = append(byName[a.Name], a)
+	}
+
+	report := &ReconcileReport{DryRun: r.DryRun}
+	for _, a := range desired {
+		report.Results = append(report.Results, r.reconcileApp(ctx, a, byName[a.Name]))
+	}
+
+	if r.Delete {
+		var extra []App
+		for _, a := range current {
+			if !names[a.Name] {
+				extra = append(extra, a)
+			}
+		}
+		sort.SliceStable(extra, func(i, j int) bool {
+			return extra[i].Name < extra[j].Name
+		})
+		for _, a := range extra {
+			result := ReconcileResult{Action: ReconcileDelete, Name: a.Name, AppID: a.AppID}
+			if !r.DryRun {
+				result.Err = r.Client.DeleteAppWithContext(ctx, a.AppID)
+				result.Applied = result.Err == nil
+			}
+			report.Results = append(report.Results, result)
+		}
+	}
+
+	if failed := report.Failed(); len(failed) > 0 {
+		return report, fmt.Errorf("%d of %d app changes failed; first error: %w",
+			len(failed), len(report.Results), failed[0].Err)
+	}
+	return report, nil
+}
+
+// reconcileApp makes one existing app (of those named like desired) match
+// desired.
+func (r Reconciler) reconcileApp(ctx context.Context, desired App, existing []App) ReconcileResult {
+	result := ReconcileResult{Name: desired.Name}
+
+	switch len(existing) {
+	case 0:
+		result.Action = ReconcileCreate
+		if !r.DryRun {
+			desired.AccountID = r.Client.AccountID
+			created, err := r.Client.CreateAppWithContext(ctx, desired)
+			result.AppID = created.AppID
+			result.Err = err
+			result.Applied = err == nil
+		}
+		return result
+	case 1:
+	default:
+		result.Action = ReconcileUpdate
+		result.Err = fmt.Errorf("more than one Arpio app exists with the name %q", desired.Name)
+		return result
+	}
+
+	current := existing[0]
+	result.AppID = current.AppID
+	result.Diff = DiffApps(current, desired)
+	switch {
+	case !result.Diff.HasChanges():
+		result.Action = ReconcileNone
+	case result.Diff.RequiresReplacement():
+		result.Action = ReconcileReplace
+		result.Err = fmt.Errorf("app %q must be replaced; delete it and reconcile again", desired.Name)
+	default:
+		result.Action = ReconcileUpdate
+		if !r.DryRun {
+			desired.AccountID = current.AccountID
+			desired.AppID = current.AppID
+			_, result.Err = r.Client.UpdateAppWithContext(ctx, desired)
+			result.Applied = result.Err == nil
+		}
+	}
+	return result
+}
+
+// Count returns the number of results with the action.
+func (r ReconcileReport) Count(action ReconcileAction) (n int) {
+	for _, result := range r.Results {
+		if result.Action == action {
+			n++
+		}
+	}
+	return n
+}
+
+// Failed returns the results with errors.
+func (r ReconcileReport) Failed() (failed []ReconcileResult) {
+	for _, result := range r.Results {
+		if result.Err != nil {
+			failed = append(failed, result)
+		}
+	}
+	return failed
+}
+
+// Summary returns a one-line summary of the report, such as
+// "1 created, 2 updated, 0 replaced, 0 deleted, 3 unchanged, 0 failed".
+func (r ReconcileReport) Summary() string {
+	verbs := []string{"created", "updated", "replaced", "deleted"}
+	if r.DryRun {
+		verbs = []string{"to create", "to update", "to replace", "to delete"}
+	}
+	actions := []ReconcileAction{ReconcileCreate, ReconcileUpdate, ReconcileReplace, ReconcileDelete}
+
+	var parts []string
+	for i, action := range actions {
+		n := 0
+		for _, result := range r.Results {
+			if result.Action == action && (r.DryRun || result.Applied) {
+				n++
+			}
+		}
+		parts = append(parts, fmt.Sprintf("%d %s", n, verbs[i]))
+	}
+	parts = append(parts,
+		fmt.Sprintf("%d unchanged", r.Count(ReconcileNone)),
+		fmt.Sprintf("%d failed", len(r.Failed())))
+	return strings.Join(parts, ", ")
+}
+
+// String renders each change in the report, followed by the summary.
+func (r ReconcileReport) String() string {
+	var b strings.Builder
+	for _, result := range r.Results {
+		switch result.Action {
+		case ReconcileNone:
+			continue
+		case ReconcileUpdate, ReconcileReplace:
+			if result.Diff.HasChanges() {
+				b.WriteString(result.Diff.String())
+				break
+			}
+			fallthrough
+		default:
+			fmt.Fprintf(&b, "%s app %q\n", result.Action, result.Name)
+		}
+		if result.Err != nil {
+			fmt.Fprintf(&b, "Error: %s\n", result.Err)
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString(r.Summary())
+	b.WriteString("\n")
+	return b.String()
+}
@@ -0,0 +1,132 @@
+package arpio_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	arpio "github.com/arpio/arpio-client-go"
+	"github.com/arpio/arpio-client-go/arpiotest"
+)
+
+func desiredApp(name string, rpo int) arpio.App {
+	return arpio.App{
+		AppType:            arpio.StandardAppType,
+		Name:               name,
+		RPO:                rpo,
+		SelectionRules:     []arpio.SelectionRule{arpio.NewTagRule("app", name)},
+		SourceAwsAccountID: "111111111111",
+		SourceRegion:       "us-east-1",
+		TargetAwsAccountID: "222222222222",
+		TargetRegion:       "us-west-2",
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	s := arpiotest.NewServer()
+	defer s.Close()
+	c, err := s.Client("acct")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	unchanged := desiredApp("unchanged", 60)
+	changed := desiredApp("changed", 60)
+	for _, a := range []arpio.App{unchanged, changed, desiredApp("extra", 60)} {
+		a.AccountID = "acct"
+		s.SeedApp(a)
+	}
+
+	desired := []arpio.App{unchanged, desiredApp("changed", 120), desiredApp("new", 60)}
+
+	// A dry run makes no changes
+	r := arpio.Reconciler{Client: c, Delete: true, DryRun: true}
+	report, err := r.Reconcile(context.Background(), desired)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "1 to create, 1 to update, 0 to replace, 1 to delete, 1 unchanged, 0 failed"
+	if report.Summary() != expected {
+		t.Fatalf("%s != %s", report.Summary(), expected)
+	}
+	if len(s.Apps("acct")) != 3 {
+		t.Fatalf("dry run changed apps: %v", s.Apps("acct"))
+	}
+
+	r.DryRun = false
+	report, err = r.Reconcile(context.Background(), desired)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = "1 created, 1 updated, 0 replaced, 1 deleted, 1 unchanged, 0 failed"
+	if report.Summary() != expected {
+		t.Fatalf("%s != %s", report.Summary(), expected)
+	}
+
+	rpos := map[string]int{}
+	for _, a := range s.Apps("acct") {
+		rpos[a.Name] = a.RPO
+	}
+	if len(rpos) != 3 || rpos["unchanged"] != 60 || rpos["changed"] != 120 || rpos["new"] != 60 {
+		t.Fatalf("unexpected apps after reconciling: %v", rpos)
+	}
+
+	// Reconciling again changes nothing
+	report, err = r.Reconcile(context.Background(), desired)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Count(arpio.ReconcileNone) != 3 {
+		t.Fatalf("unexpected changes: %s", report)
+	}
+}
+
+func TestReconcileReplace(t *testing.T) {
+	s := arpiotest.NewServer()
+	defer s.Close()
+	c, err := s.Client("acct")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := desiredApp("web", 60)
+	a.AccountID = "acct"
+	s.SeedApp(a)
+
+	// Apps not in the desired set are kept unless Delete is set
+	s.SeedApp(arpio.App{AccountID: "acct", Name: "other"})
+
+	moved := desiredApp("web", 60)
+	moved.TargetRegion = "us-east-2"
+	report, err := arpio.Reconciler{Client: c}.Reconcile(context.Background(), []arpio.App{moved})
+	if err == nil {
+		t.Fatal("expected an error for an app that must be replaced")
+	}
+	if report.Count(arpio.ReconcileReplace) != 1 || len(report.Failed()) != 1 {
+		t.Fatalf("unexpected report: %s", report)
+	}
+	if !strings.Contains(report.String(), "TargetRegion: \"us-west-2\" -> \"us-east-2\" (forces replacement)") {
+		t.Fatalf("unexpected report: %s", report)
+	}
+	if len(s.Apps("acct")) != 2 {
+		t.Fatalf("unexpected apps: %v", s.Apps("acct"))
+	}
+}
+
+func TestReconcileInvalid(t *testing.T) {
+	c, err := arpio.NewClient("http://localhost", "id", "secret", "acct")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := arpio.Reconciler{Client: c}
+
+	// Nothing is requested for invalid or duplicate desired apps
+	_, err = r.Reconcile(context.Background(), []arpio.App{desiredApp("web", 0)})
+	if err == nil {
+		t.Fatal("expected a validation error")
+	}
+	_, err = r.Reconcile(context.Background(), []arpio.App{desiredApp("web", 60), desiredApp("web", 60)})
+	if err == nil {
+		t.Fatal("expected a duplicate name error")
+	}
+}
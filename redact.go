@@ -0,0 +1,185 @@
+package arpio
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// RedactedValue replaces redacted values.
+const RedactedValue = "[REDACTED]"
+
+// Redactor scrubs secrets and customer data from request and response data
+// before it is logged or attached to errors.
+type Redactor struct {
+	// Headers are the names of HTTP headers whose values are redacted.
+	Headers []string
+
+	// JSONPaths identify JSON values to redact.  A path is a dot-separated
+	// list of object keys, where "*" matches any key or array element.  A
+	// path matches wherever its keys appear in sequence, at any depth, so
+	// "notificationEmails.*" redacts every notification email in a single
+	// App or a list of them.
+	JSONPaths []string
+
+	// SensitiveTagKeys are patterns of tag keys whose values are redacted,
+	// in resource tag maps and in tag selection rules.  Patterns are
+	// case-insensitive and may contain "*" wildcards.
+	SensitiveTagKeys []string
+}
+
+// DefaultRedactor returns the Redactor that NewClient configures.  It
+// redacts API credentials, notification emails and the values of tags whose
+// keys look like they hold secrets.
+func DefaultRedactor() *Redactor {
+	return &Redactor{
+		Headers: []string{
+			"X-Api-Key",
+			"Authorization",
+		},
+		JSONPaths: []string{
+			"notificationEmails.*",
+		},
+		SensitiveTagKeys: []string{
+			"*password*",
+			"*secret*",
+			"*token*",
+			"*credential*",
+			"*private*key*",
+		},
+	}
+}
+
+// RedactHeader returns a copy of h with the values of the Redactor's headers
+// replaced.  LoggingMiddleware and the arpiotest cassette recorder use it
+// for the headers they write out.  A nil Redactor behaves like
+// DefaultRedactor.
+func (r *Redactor) RedactHeader(h http.Header) http.Header {
+	if r == nil {
+		r = DefaultRedactor()
+	}
+	redacted := http.Header{}
+	for k, v := range h {
+		redacted[k] = append([]string(nil), v...)
+	}
+	for _, name := range r.Headers {
+		for i := range redacted[http.CanonicalHeaderKey(name)] {
+			redacted[http.CanonicalHeaderKey(name)][i] = RedactedValue
+		}
+	}
+	return redacted
+}
+
+// RedactJSON returns a copy of the JSON document b with sensitive values
+// replaced.  Only documents with sensitive values are re-encoded (with
+// sorted keys and compact whitespace); others, including documents that are
+// not valid JSON, are returned unchanged.  A nil Redactor behaves like
+// DefaultRedactor.
+func (r *Redactor) RedactJSON(b []byte) []byte {
+	if r == nil {
+		r = DefaultRedactor()
+	}
+	if len(b) == 0 {
+		return b
+	}
+
+	var doc interface{}
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	if err := d.Decode(&doc); err != nil {
+		return b
+	}
+
+	paths := make([][]string, len(r.JSONPaths))
+	for i, p := range r.JSONPaths {
+		paths[i] = strings.Split(p, ".")
+	}
+	doc, changed := r.redactValue(doc, nil, paths)
+	if !changed {
+		return b
+	}
+
+	redacted, err := json.Marshal(doc)
+	if err != nil {
+		return b
+	}
+	return redacted
+}
+
+// redactValue redacts v, found at path, and its children, and reports
+// whether anything was redacted.
+func (r *Redactor) redactValue(v interface{}, path []string, paths [][]string) (redacted interface{}, changed bool) {
+	for _, p := range paths {
+		if pathSuffixMatches(path, p) {
+			return RedactedValue, true
+		}
+	}
+
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, child := range t {
+			var c bool
+			t[k], c = r.redactValue(child, append(path, k), paths)
+			changed = changed || c
+		}
+
+		// Resource tag maps
+		if tags, ok := t["tags"].(map[string]interface{}); ok {
+			for k := range tags {
+				if r.isSensitiveTagKey(k) {
+					tags[k] = RedactedValue
+					changed = true
+				}
+			}
+		}
+
+		// Tag selection rules
+		if t["ruleType"] == TagRuleType || t["ruleType"] == TagMatchRuleType {
+			if name, ok := t["name"].(string); ok && r.isSensitiveTagKey(name) {
+				if _, ok := t["value"]; ok {
+					t["value"] = RedactedValue
+					changed = true
+				}
+				if values, ok := t["values"].([]interface{}); ok {
+					for i := range values {
+						values[i] = RedactedValue
+						changed = true
+					}
+				}
+			}
+		}
+	case []interface{}:
+		for i, child := range t {
+			var c bool
+			t[i], c = r.redactValue(child, append(path, strconv.Itoa(i)), paths)
+			changed = changed || c
+		}
+	}
+	return v, changed
+}
+
+func (r *Redactor) isSensitiveTagKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, pattern := range r.SensitiveTagKeys {
+		if wildcardMatch(strings.ToLower(pattern), key) {
+			return true
+		}
+	}
+	return false
+}
+
+// pathSuffixMatches reports whether the end of path matches pattern.
+func pathSuffixMatches(path, pattern []string) bool {
+	if len(pattern) == 0 || len(pattern) > len(path) {
+		return false
+	}
+	offset := len(path) - len(pattern)
+	for i, p := range pattern {
+		if p != "*" && p != path[offset+i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,114 @@
+package arpio
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRedactJSON(t *testing.T) {
+	app := App{
+		Name:               "app",
+		NotificationEmails: []string{"a@example.com"},
+		SelectionRules: []SelectionRule{
+			NewTagRule("db-password", "hunter2"),
+			NewTagRule("env", "prod"),
+		},
+	}
+	b, err := json.Marshal(app)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	redacted := string(DefaultRedactor().RedactJSON(b))
+	if strings.Contains(redacted, "a@example.com") || strings.Contains(redacted, "hunter2") {
+		t.Fatalf("secrets were not redacted: %s", redacted)
+	}
+	if !strings.Contains(redacted, `"prod"`) || !strings.Contains(redacted, `"name":"app"`) {
+		t.Fatalf("too much was redacted: %s", redacted)
+	}
+}
+
+func TestRedactJSONTagsAndPaths(t *testing.T) {
+	r := &Redactor{
+		JSONPaths:        []string{"restoreMetadata.*"},
+		SensitiveTagKeys: []string{"API_TOKEN"},
+	}
+	in := `[{"arn":"arn:a","tags":{"api_token":"t","team":"x"},"extras":[{"restoreMetadata":{"k":"v"}}]}]`
+	expected := `[{"arn":"arn:a","extras":[{"restoreMetadata":{"k":"[REDACTED]"}}],"tags":{"api_token":"[REDACTED]","team":"x"}}]`
+
+	if out := string(r.RedactJSON([]byte(in))); out != expected {
+		t.Fatalf("%s != %s", out, expected)
+	}
+	if out := string(r.RedactJSON([]byte("not json"))); out != "not json" {
+		t.Fatalf("invalid JSON was modified: %s", out)
+	}
+}
+
+func TestRedactJSONUnchanged(t *testing.T) {
+	// Bodies without sensitive values keep their original bytes
+	in := `{ "name": "app",  "rpo": 60 }`
+	if out := string(DefaultRedactor().RedactJSON([]byte(in))); out != in {
+		t.Fatalf("%s != %s", out, in)
+	}
+}
+
+func TestRedactHeader(t *testing.T) {
+	h := http.Header{}
+	h.Set("X-Api-Key", "secret")
+	h.Set("Accept", "*/*")
+
+	redacted := DefaultRedactor().RedactHeader(h)
+	expected := http.Header{
+		"X-Api-Key": {RedactedValue},
+		"Accept":    {"*/*"},
+	}
+	if !reflect.DeepEqual(redacted, expected) {
+		t.Fatalf("%v != %v", redacted, expected)
+	}
+	if h.Get("X-Api-Key") != "secret" {
+		t.Fatalf("the original header was modified")
+	}
+}
+
+func TestWildcardMatch(t *testing.T) {
+	cases := []struct {
+		pattern, s string
+		match      bool
+	}{
+		{"abc", "abc", true},
+		{"abc", "abcd", false},
+		{"*", "", true},
+		{"a*", "abc", true},
+		{"*c", "abc", true},
+		{"*secret*", "my-secret-tag", true},
+		{"a*b*c", "aXbYc", true},
+		{"a*b*c", "acb", false},
+		{"ab*b", "ab", false},
+	}
+	for _, tc := range cases {
+		if wildcardMatch(tc.pattern, tc.s) != tc.match {
+			t.Errorf("wildcardMatch(%q, %q) != %v", tc.pattern, tc.s, tc.match)
+		}
+	}
+}
+
+func TestAPIErrorBodyIsRedacted(t *testing.T) {
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"notificationEmails":["a@example.com"]}`))
+	})
+	defer server.Close()
+
+	_, err := c.ListApps()
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected *APIError, got %v", err)
+	}
+	if strings.Contains(string(apiErr.Body), "a@example.com") {
+		t.Fatalf("error body was not redacted: %s", apiErr.Body)
+	}
+}
@@ -0,0 +1,152 @@
+package arpio
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+// SelectionRuleDecoder decodes the JSON of a selection rule.  The rule it
+// returns must marshal back to JSON with the same "ruleType".
+type SelectionRuleDecoder func(raw json.RawMessage) (SelectionRule, error)
+
+// StagedExtraDecoder decodes the JSON of a staged extra.  The extra it
+// returns must marshal back to JSON with the same "type".
+type StagedExtraDecoder func(raw json.RawMessage) (StagedExtra, error)
+
+var (
+	registryMu            sync.RWMutex
+	selectionRuleDecoders = map[string]SelectionRuleDecoder{}
+	stagedExtraDecoders   = map[string]StagedExtraDecoder{}
+)
+
+// RegisterSelectionRuleType makes App.UnmarshalJSON decode selection rules
+// with the specified ruleType using decoder, replacing any decoder already
+// registered for the type.  Rules with unregistered types are decoded as
+// UnknownRule.  It is safe to call concurrently, but is typically called
+// from an init function.  It panics if ruleType is empty or decoder is nil.
+func RegisterSelectionRuleType(ruleType string, decoder SelectionRuleDecoder) {
+	if ruleType == "" {
+		panic("arpio: RegisterSelectionRuleType ruleType is empty")
+	}
+	if decoder == nil {
+		panic("arpio: RegisterSelectionRuleType decoder is nil")
+	}
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	selectionRuleDecoders[ruleType] = decoder
+}
+
+// RegisterStagedExtraType makes StagedResource.UnmarshalJSON decode extras
+// with the specified extraType using decoder, replacing any decoder already
+// registered for the type.  Extras with unregistered types are decoded as
+// UnknownExtra.  It is safe to call concurrently, but is typically called
+// from an init function.  It panics if extraType is empty or decoder is nil.
+func RegisterStagedExtraType(extraType string, decoder StagedExtraDecoder) {
+	if extraType == "" {
+		panic("arpio: RegisterStagedExtraType extraType is empty")
+	}
+	if decoder == nil {
+		panic("arpio: RegisterStagedExtraType decoder is nil")
+	}
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	stagedExtraDecoders[extraType] = decoder
+}
+
+func lookupSelectionRuleDecoder(ruleType string) (SelectionRuleDecoder, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	d, ok := selectionRuleDecoders[ruleType]
+	return d, ok
+}
+
+func lookupStagedExtraDecoder(extraType string) (StagedExtraDecoder, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	d, ok := stagedExtraDecoders[extraType]
+	return d, ok
+}
+
+func init() {
+	RegisterSelectionRuleType(ArnRuleType, func(raw json.RawMessage) (SelectionRule, error) {
+		var r ArnRule
+		err := json.Unmarshal(raw, &r)
+		return r, err
+	})
+	RegisterSelectionRuleType(TagRuleType, func(raw json.RawMessage) (SelectionRule, error) {
+		var r TagRule
+		err := json.Unmarshal(raw, &r)
+		return r, err
+	})
+	RegisterSelectionRuleType(ArnPatternRuleType, func(raw json.RawMessage) (SelectionRule, error) {
+		var r ArnPatternRule
+		err := json.Unmarshal(raw, &r)
+		return r, err
+	})
+	RegisterSelectionRuleType(ResourceTypeRuleType, func(raw json.RawMessage) (SelectionRule, error) {
+		var r ResourceTypeRule
+		err := json.Unmarshal(raw, &r)
+		return r, err
+	})
+	RegisterSelectionRuleType(TagMatchRuleType, func(raw json.RawMessage) (SelectionRule, error) {
+		var r TagMatchRule
+		err := json.Unmarshal(raw, &r)
+		return r, err
+	})
+	RegisterSelectionRuleType(AllOfRuleType, func(raw json.RawMessage) (SelectionRule, error) {
+		var r AllOfRule
+		err := json.Unmarshal(raw, &r)
+		return r, err
+	})
+	RegisterSelectionRuleType(AnyOfRuleType, func(raw json.RawMessage) (SelectionRule, error) {
+		var r AnyOfRule
+		err := json.Unmarshal(raw, &r)
+		return r, err
+	})
+	RegisterSelectionRuleType(NotRuleType, func(raw json.RawMessage) (SelectionRule, error) {
+		var r NotRule
+		err := json.Unmarshal(raw, &r)
+		return r, err
+	})
+
+	RegisterStagedExtraType(BackupRecoveryPointExtraType, func(raw json.RawMessage) (StagedExtra, error) {
+		var e BackupRecoveryPointExtra
+		err := json.Unmarshal(raw, &e)
+		return e, err
+	})
+	RegisterStagedExtraType(BackupVaultExtraType, func(raw json.RawMessage) (StagedExtra, error) {
+		var e BackupVaultExtra
+		err := json.Unmarshal(raw, &e)
+		return e, err
+	})
+	RegisterStagedExtraType(EC2ImageExtraType, func(raw json.RawMessage) (StagedExtra, error) {
+		var e EC2ImageExtra
+		err := json.Unmarshal(raw, &e)
+		return e, err
+	})
+	RegisterStagedExtraType(EC2SnapshotExtraType, func(raw json.RawMessage) (StagedExtra, error) {
+		var e EC2SnapshotExtra
+		err := json.Unmarshal(raw, &e)
+		return e, err
+	})
+	RegisterStagedExtraType(KMSKeyExtraType, func(raw json.RawMessage) (StagedExtra, error) {
+		var e KMSKeyExtra
+		err := json.Unmarshal(raw, &e)
+		return e, err
+	})
+	RegisterStagedExtraType(RDSDBClusterSnapshotExtraType, func(raw json.RawMessage) (StagedExtra, error) {
+		var e RDSDBClusterSnapshotExtra
+		err := json.Unmarshal(raw, &e)
+		return e, err
+	})
+	RegisterStagedExtraType(RDSDBSnapshotExtraType, func(raw json.RawMessage) (StagedExtra, error) {
+		var e RDSDBSnapshotExtra
+		err := json.Unmarshal(raw, &e)
+		return e, err
+	})
+	RegisterStagedExtraType(RDSOptionGroupExtraType, func(raw json.RawMessage) (StagedExtra, error) {
+		var e RDSOptionGroupExtra
+		err := json.Unmarshal(raw, &e)
+		return e, err
+	})
+}
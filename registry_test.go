@@ -0,0 +1,101 @@
+package arpio
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+type testPreviewRule struct {
+	RuleType string `json:"ruleType"`
+	Query    string `json:"query"`
+}
+
+func (r testPreviewRule) GetRuleType() string {
+	return r.RuleType
+}
+
+type testPreviewExtra struct {
+	Type        string `json:"type"`
+	Environment string `json:"environment"`
+	Thing       string `json:"thing"`
+}
+
+func (e testPreviewExtra) GetType() string        { return e.Type }
+func (e testPreviewExtra) GetEnvironment() string { return e.Environment }
+
+// unregister removes the decoders registered for a test type, so they don't
+// leak into other tests.
+func unregister(typ string) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	delete(selectionRuleDecoders, typ)
+	delete(stagedExtraDecoders, typ)
+}
+
+func TestRegisterSelectionRuleType(t *testing.T) {
+	defer unregister("testPreview")
+	RegisterSelectionRuleType("testPreview", func(raw json.RawMessage) (SelectionRule, error) {
+		var r testPreviewRule
+		err := json.Unmarshal(raw, &r)
+		return r, err
+	})
+
+	in := `{"selectionRules":[{"ruleType":"testPreview","query":"q"}]}`
+	var app App
+	err := json.Unmarshal([]byte(in), &app)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, ok := app.SelectionRules[0].(testPreviewRule)
+	if !ok || r.Query != "q" {
+		t.Fatalf("unexpected rule %#v", app.SelectionRules[0])
+	}
+	if len(app.UnknownSelectionRuleTypes()) != 0 {
+		t.Fatalf("registered rule type reported as unknown")
+	}
+}
+
+func TestRegisterStagedExtraType(t *testing.T) {
+	defer unregister("testPreview")
+	RegisterStagedExtraType("testPreview", func(raw json.RawMessage) (StagedExtra, error) {
+		var e testPreviewExtra
+		err := json.Unmarshal(raw, &e)
+		return e, err
+	})
+
+	in := `{"arn":"arn:a","extras":[{"type":"testPreview","environment":"target","thing":"x"}]}`
+	var sr StagedResource
+	err := json.Unmarshal([]byte(in), &sr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, ok := sr.Extras[0].(testPreviewExtra)
+	if !ok || e.Thing != "x" {
+		t.Fatalf("unexpected extra %#v", sr.Extras[0])
+	}
+}
+
+func TestRegisterInvalid(t *testing.T) {
+	decodeRule := func(raw json.RawMessage) (SelectionRule, error) { return nil, nil }
+	decodeExtra := func(raw json.RawMessage) (StagedExtra, error) { return nil, nil }
+	cases := map[string]func(){
+		"empty rule type":   func() { RegisterSelectionRuleType("", decodeRule) },
+		"nil rule decoder":  func() { RegisterSelectionRuleType("testInvalid", nil) },
+		"empty extra type":  func() { RegisterStagedExtraType("", decodeExtra) },
+		"nil extra decoder": func() { RegisterStagedExtraType("testInvalid", nil) },
+	}
+	for name, register := range cases {
+		func() {
+			defer unregister("testInvalid")
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: expected a panic", name)
+				}
+			}()
+			register()
+		}()
+	}
+	if _, ok := lookupSelectionRuleDecoder(""); ok {
+		t.Fatal("empty rule type was registered")
+	}
+}
@@ -0,0 +1,194 @@
+package arpio
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy controls how a Client retries failed API requests.  The zero
+// value performs no retries.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts made for a request,
+	// including the first one.  Values <= 1 disable retries.
+	MaxAttempts int
+
+	// BackoffBase is the delay before the first retry.  The delay doubles
+	// with each subsequent retry.
+	BackoffBase time.Duration
+
+	// BackoffCap is the maximum delay between attempts.  Zero means the
+	// delay is not capped.  Retry-After delays sent by the API are not
+	// subject to the cap; see MaxRetryAfter.
+	BackoffCap time.Duration
+
+	// MaxRetryAfter is the longest Retry-After delay the client waits for.
+	// If the API asks for a longer wait, the request is not retried and its
+	// error is returned.  Zero means Retry-After delays are not limited.
+	MaxRetryAfter time.Duration
+
+	// Jitter is the fraction (0 to 1) of each backoff delay that is
+	// randomized, to keep concurrent clients from retrying in lockstep.
+	Jitter float64
+
+	// RetryableStatuses are the HTTP response statuses that are retried.
+	RetryableStatuses []int
+
+	// RetryableMethods are the HTTP methods that are retried.  Only
+	// idempotent methods are retryable by default; add http.MethodPost to
+	// opt in to retrying creates.
+	RetryableMethods []string
+}
+
+// DefaultRetryPolicy returns the RetryPolicy that NewClient configures:
+// three attempts with exponential backoff for throttling, server errors and
+// connection failures of idempotent requests.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:   3,
+		BackoffBase:   500 * time.Millisecond,
+		BackoffCap:    30 * time.Second,
+		MaxRetryAfter: time.Minute,
+		Jitter:        0.5,
+		RetryableStatuses: []int{
+			http.StatusTooManyRequests,
+			http.StatusInternalServerError,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+		RetryableMethods: []string{
+			http.MethodGet,
+			http.MethodPut,
+			http.MethodDelete,
+		},
+	}
+}
+
+// RetryMiddleware retries requests that fail according to policy.  Retries
+// are logged to logger, which may be nil.
+func RetryMiddleware(policy RetryPolicy, logger Logger) Middleware {
+	if logger == nil {
+		logger = NopLogger{}
+	}
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(ctx context.Context, req *APIRequest) (resp *APIResponse, err error) {
+			for attempt := 1; ; attempt++ {
+				resp, err = next(ctx, req)
+				var status int
+				var header http.Header
+				if resp != nil {
+					status = resp.StatusCode
+					header = resp.Header
+				}
+				if attempt >= policy.MaxAttempts || !policy.shouldRetry(ctx, req.Method, status, err) {
+					return resp, err
+				}
+
+				delay, ok := policy.delay(attempt, header)
+				if !ok {
+					return resp, err
+				}
+				logger.Log(LevelDebug, "Retrying Arpio API request",
+					LogFieldMethod, req.Method,
+					LogFieldPath, req.RelativeURL,
+					LogFieldStatus, status,
+					LogFieldAttempt, attempt,
+					LogFieldDelay, delay,
+					LogFieldError, err)
+				if sleepErr := sleepContext(ctx, delay); sleepErr != nil {
+					return resp, sleepErr
+				}
+			}
+		}
+	}
+}
+
+// shouldRetry decides whether an attempt that ended with the specified
+// status and error should be retried.
+func (p RetryPolicy) shouldRetry(ctx context.Context, method string, status int, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if !SliceContainsString(method, p.RetryableMethods) {
+		return false
+	}
+	var apiErr *APIError
+	if err != nil && !errors.As(err, &apiErr) {
+		return isRetryableError(err)
+	}
+	return SliceContainsInt(status, p.RetryableStatuses)
+}
+
+// delay computes how long to wait before the next attempt, given the number
+// of the attempt that just failed and its response header (which may be nil).
+// ok is false if the response asks for a wait longer than MaxRetryAfter.
+func (p RetryPolicy) delay(attempt int, header http.Header) (d time.Duration, ok bool) {
+	if d, ok := parseRetryAfter(header, time.Now()); ok {
+		if p.MaxRetryAfter > 0 && d > p.MaxRetryAfter {
+			return 0, false
+		}
+		return d, true
+	}
+
+	d = p.BackoffBase
+	for i := 1; i < attempt; i++ {
+		d *= 2
+		if p.BackoffCap > 0 && d >= p.BackoffCap {
+			break
+		}
+	}
+	if p.BackoffCap > 0 && d > p.BackoffCap {
+		d = p.BackoffCap
+	}
+
+	if p.Jitter > 0 {
+		jitter := p.Jitter
+		if jitter > 1 {
+			jitter = 1
+		}
+		d -= time.Duration(rand.Float64() * jitter * float64(d))
+	}
+	return d, true
+}
+
+// parseRetryAfter reads the Retry-After header, which holds either a number
+// of seconds or an HTTP date.
+func parseRetryAfter(header http.Header, now time.Time) (d time.Duration, ok bool) {
+	v := header.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(v); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		d = t.Sub(now)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
+
+// isRetryableError reports whether a transport error is likely transient.
+func isRetryableError(err error) bool {
+	if errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
@@ -0,0 +1,128 @@
+package arpio
+
+import (
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyDelay(t *testing.T) {
+	p := RetryPolicy{
+		BackoffBase: time.Second,
+		BackoffCap:  5 * time.Second,
+	}
+	expected := []time.Duration{
+		time.Second,
+		2 * time.Second,
+		4 * time.Second,
+		5 * time.Second,
+		5 * time.Second,
+	}
+	for i, e := range expected {
+		if d, _ := p.delay(i+1, nil); d != e {
+			t.Fatalf("attempt %d: %s != %s", i+1, d, e)
+		}
+	}
+
+	header := http.Header{}
+	header.Set("Retry-After", "120")
+	if d, ok := p.delay(1, header); !ok || d != 2*time.Minute {
+		t.Fatalf("%s != %s", d, 2*time.Minute)
+	}
+
+	// Longer waits than MaxRetryAfter aren't retried
+	p.MaxRetryAfter = time.Minute
+	if _, ok := p.delay(1, header); ok {
+		t.Fatal("expected no retry for a Retry-After over MaxRetryAfter")
+	}
+}
+
+func TestRetryPolicyJitter(t *testing.T) {
+	p := RetryPolicy{
+		BackoffBase: time.Second,
+		Jitter:      0.5,
+	}
+	for i := 0; i < 100; i++ {
+		d, _ := p.delay(1, nil)
+		if d < 500*time.Millisecond || d > time.Second {
+			t.Fatalf("delay %s out of range", d)
+		}
+	}
+}
+
+func TestParseRetryAfterDate(t *testing.T) {
+	now := time.Date(2021, 9, 3, 12, 0, 0, 0, time.UTC)
+	header := http.Header{}
+	header.Set("Retry-After", now.Add(30*time.Second).Format(http.TimeFormat))
+
+	d, ok := parseRetryAfter(header, now)
+	if !ok || d != 30*time.Second {
+		t.Fatalf("unexpected result %s, %v", d, ok)
+	}
+}
+
+func TestClientRetries(t *testing.T) {
+	var calls int32
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte("[]"))
+	})
+	defer server.Close()
+	c.RetryPolicy.BackoffBase = time.Millisecond
+
+	_, err := c.ListApps()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 3 {
+		t.Fatalf("%d calls != 3", calls)
+	}
+}
+
+func TestClientDoesNotWaitForLongRetryAfter(t *testing.T) {
+	var calls int32
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+	defer server.Close()
+
+	start := time.Now()
+	_, err := c.ListApps()
+	if !IsRateLimited(err) {
+		t.Fatalf("expected a rate limiting error, got %v", err)
+	}
+	if calls != 1 || time.Since(start) > 10*time.Second {
+		t.Fatalf("%d calls in %s, expected 1 call without waiting", calls, time.Since(start))
+	}
+}
+
+func TestClientDoesNotRetryPost(t *testing.T) {
+	var calls int32
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	defer server.Close()
+	c.RetryPolicy.BackoffBase = time.Millisecond
+
+	_, err := c.CreateApp(c.NewApp())
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if calls != 1 {
+		t.Fatalf("%d calls != 1", calls)
+	}
+
+	calls = 0
+	c.RetryPolicy.RetryableMethods = append(c.RetryPolicy.RetryableMethods, http.MethodPost)
+	_, _ = c.CreateApp(c.NewApp())
+	if calls != 3 {
+		t.Fatalf("%d calls != 3", calls)
+	}
+}
@@ -0,0 +1,69 @@
+package arpio
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ResourceMatcher is implemented by selection rules that can be evaluated
+// locally, without the Arpio service.
+type ResourceMatcher interface {
+	Matches(resource StagedResource) bool
+}
+
+// ResourceSelection is a resource selected by an App's selection rules.
+type ResourceSelection struct {
+	Resource StagedResource
+
+	// Rules are the selection rules that matched the resource, in the order
+	// they appear in the App.
+	Rules []SelectionRule
+}
+
+// UnevaluableRulesError is returned by SelectResources when some of the
+// App's selection rules can't be evaluated locally (for example,
+// UnknownRule).  The selection computed from the other rules is still
+// returned, but may be incomplete.
+type UnevaluableRulesError struct {
+	RuleTypes []string
+}
+
+func (e *UnevaluableRulesError) Error() string {
+	return fmt.Sprintf("selection rules of these types can't be evaluated "+
+		"locally: %s", strings.Join(e.RuleTypes, ", "))
+}
+
+// SelectResources evaluates the App's selection rules against resources
+// and returns the resources that at least one rule matches, in their
+// original order, along with the rules that matched each.  If any rule,
+// including rules nested in composite rules, doesn't implement
+// ResourceMatcher, an *UnevaluableRulesError is returned with the selection
+// made by the remaining rules.
+func (a App) SelectResources(resources []StagedResource) (selected []ResourceSelection, err error) {
+	var unevaluable []string
+	walkSelectionRules(a.SelectionRules, func(r SelectionRule) {
+		if _, ok := r.(ResourceMatcher); !ok && !SliceContainsString(r.GetRuleType(), unevaluable) {
+			unevaluable = append(unevaluable, r.GetRuleType())
+		}
+	})
+
+	for _, resource := range resources {
+		var rules []SelectionRule
+		for _, r := range a.SelectionRules {
+			if m, ok := r.(ResourceMatcher); ok && m.Matches(resource) {
+				rules = append(rules, r)
+			}
+		}
+		if len(rules) > 0 {
+			selected = append(selected, ResourceSelection{
+				Resource: resource,
+				Rules:    rules,
+			})
+		}
+	}
+
+	if len(unevaluable) > 0 {
+		err = &UnevaluableRulesError{RuleTypes: unevaluable}
+	}
+	return selected, err
+}
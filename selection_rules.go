@@ -1,8 +1,25 @@
 package arpio
 
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/arpio/arpio-client-go/arn"
+)
+
 const (
-	ArnRuleType = "arn"
-	TagRuleType = "tag"
+	ArnRuleType          = "arn"
+	ArnPatternRuleType   = "arnPattern"
+	ResourceTypeRuleType = "resourceType"
+	TagRuleType          = "tag"
+	TagMatchRuleType     = "tagMatch"
+)
+
+// Enumeration of the ways a TagMatchRule compares tag values.
+const (
+	TagMatchExists = "exists"
+	TagMatchPrefix = "prefix"
+	TagMatchOneOf  = "oneOf"
 )
 
 type SelectionRule interface {
@@ -33,6 +50,22 @@ func NewArnRule(arns []string) ArnRule {
 	}
 }
 
+// Matches reports whether the resource's ARN is one of the rule's ARNs.
+func (r ArnRule) Matches(resource StagedResource) bool {
+	return SliceContainsString(resource.ARN, r.Arns)
+}
+
+// Validate reports the first of the rule's ARNs that is not a valid ARN, as
+// an *arn.Error.
+func (r ArnRule) Validate() error {
+	for _, a := range r.Arns {
+		if err := arn.Validate(a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 type TagRule struct {
 	selectionRule
 	Name  string `json:"name"`
@@ -48,3 +81,135 @@ func NewTagRule(name, value string) TagRule {
 		Value:         value,
 	}
 }
+
+// Matches reports whether the resource has a tag with the rule's name and
+// value.
+func (r TagRule) Matches(resource StagedResource) bool {
+	v, ok := resource.Tags[r.Name]
+	return ok && v == r.Value
+}
+
+type ArnPatternRule struct {
+	selectionRule
+	Patterns []string `json:"patterns"`
+}
+
+// NewArnPatternRule creates an ArnPatternRule that will match resources
+// with ARNs matching any of the specified patterns, in which "*" matches any
+// sequence of characters (for example "arn:aws:s3:::logs-*").
+func NewArnPatternRule(patterns []string) ArnPatternRule {
+	return ArnPatternRule{
+		selectionRule: selectionRule{RuleType: ArnPatternRuleType},
+		Patterns:      patterns,
+	}
+}
+
+// Matches reports whether the resource's ARN matches any of the rule's
+// patterns.
+func (r ArnPatternRule) Matches(resource StagedResource) bool {
+	for _, p := range r.Patterns {
+		if wildcardMatch(p, resource.ARN) {
+			return true
+		}
+	}
+	return false
+}
+
+type ResourceTypeRule struct {
+	selectionRule
+	ResourceTypes []string `json:"resourceTypes"`
+}
+
+// NewResourceTypeRule creates a ResourceTypeRule that will match resources
+// of the specified AWS resource types (for example "AWS::RDS::DBCluster").
+func NewResourceTypeRule(resourceTypes []string) ResourceTypeRule {
+	return ResourceTypeRule{
+		selectionRule: selectionRule{RuleType: ResourceTypeRuleType},
+		ResourceTypes: resourceTypes,
+	}
+}
+
+// Matches reports whether the resource's type is one of the rule's types.
+func (r ResourceTypeRule) Matches(resource StagedResource) bool {
+	return SliceContainsString(resource.Type, r.ResourceTypes)
+}
+
+type TagMatchRule struct {
+	selectionRule
+	Name   string   `json:"name"`
+	Match  string   `json:"match"`
+	Values []string `json:"values,omitempty"`
+}
+
+// NewTagExistsRule creates a TagMatchRule that will match resources with
+// the specified tag, whatever its value.
+func NewTagExistsRule(name string) TagMatchRule {
+	return TagMatchRule{
+		selectionRule: selectionRule{RuleType: TagMatchRuleType},
+		Name:          name,
+		Match:         TagMatchExists,
+	}
+}
+
+// NewTagPrefixRule creates a TagMatchRule that will match resources with
+// the specified tag whose value starts with prefix.
+func NewTagPrefixRule(name, prefix string) TagMatchRule {
+	return TagMatchRule{
+		selectionRule: selectionRule{RuleType: TagMatchRuleType},
+		Name:          name,
+		Match:         TagMatchPrefix,
+		Values:        []string{prefix},
+	}
+}
+
+// NewTagOneOfRule creates a TagMatchRule that will match resources with
+// the specified tag whose value is one of values.
+func NewTagOneOfRule(name string, values []string) TagMatchRule {
+	return TagMatchRule{
+		selectionRule: selectionRule{RuleType: TagMatchRuleType},
+		Name:          name,
+		Match:         TagMatchOneOf,
+		Values:        values,
+	}
+}
+
+// Matches reports whether the resource has the rule's tag with a value
+// accepted by the rule's Match mode.  Unrecognized modes don't match.
+func (r TagMatchRule) Matches(resource StagedResource) bool {
+	v, ok := resource.Tags[r.Name]
+	if !ok {
+		return false
+	}
+	switch r.Match {
+	case TagMatchExists:
+		return true
+	case TagMatchPrefix:
+		for _, prefix := range r.Values {
+			if strings.HasPrefix(v, prefix) {
+				return true
+			}
+		}
+		return false
+	case TagMatchOneOf:
+		return SliceContainsString(v, r.Values)
+	default:
+		return false
+	}
+}
+
+// UnknownRule holds a selection rule with a type this client doesn't
+// recognize, which usually means the Arpio service is newer than the
+// client.  The rule's JSON is preserved so it is sent back unchanged when
+// the App is updated.
+type UnknownRule struct {
+	selectionRule
+	Raw json.RawMessage
+}
+
+// MarshalJSON returns the rule's original JSON.
+func (r UnknownRule) MarshalJSON() ([]byte, error) {
+	if len(r.Raw) == 0 {
+		return json.Marshal(r.selectionRule)
+	}
+	return r.Raw, nil
+}
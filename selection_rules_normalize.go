@@ -0,0 +1,380 @@
+package arpio
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+// RedundantRule is a selection rule reported by NormalizeSelectionRules
+// because another rule already selects everything it selects.
+type RedundantRule struct {
+	// Rule is the redundant rule.  For ARNs, ARN patterns or resource types
+	// covered by other rules, it is a rule of the same type with just those
+	// values.
+	Rule SelectionRule
+
+	// SubsumedBy is the rule that makes Rule redundant.
+	SubsumedBy SelectionRule
+}
+
+// NormalizeSelectionRules returns an equivalent, deterministic form of
+// rules: the ArnRule, ArnPatternRule and ResourceTypeRule lists are each
+// merged into a single rule, lists are sorted and deduplicated, identical
+// rules are removed, and the result is sorted.  Composite rules are
+// normalized recursively.
+//
+// It also reports the rules that are redundant because other rules subsume
+// them, including the removed duplicates and the list values that an
+// earlier rule of the same type already lists.  Subsumed rules other than
+// duplicates are left in the normalized rules, since removing them is a
+// choice for the caller.
+func NormalizeSelectionRules(rules []SelectionRule) (normalized []SelectionRule, redundant []RedundantRule) {
+	var arns, arnPatterns, resourceTypes []string
+	var hasArns, hasArnPatterns, hasResourceTypes bool
+	var others, lists []SelectionRule
+	for _, r := range rules {
+		switch t := r.(type) {
+		case ArnRule:
+			arns = append(arns, t.Arns...)
+			hasArns = true
+		case ArnPatternRule:
+			arnPatterns = append(arnPatterns, t.Patterns...)
+			hasArnPatterns = true
+		case ResourceTypeRule:
+			resourceTypes = append(resourceTypes, t.ResourceTypes...)
+			hasResourceTypes = true
+		default:
+			others = append(others, normalizeRule(r))
+			continue
+		}
+		redundant = append(redundant, repeatedValues(r, lists)...)
+		lists = append(lists, r)
+	}
+
+	if hasArns {
+		normalized = append(normalized, NewArnRule(sortedUnique(arns)))
+	}
+	if hasArnPatterns {
+		normalized = append(normalized, NewArnPatternRule(sortedUnique(arnPatterns)))
+	}
+	if hasResourceTypes {
+		normalized = append(normalized, NewResourceTypeRule(sortedUnique(resourceTypes)))
+	}
+
+	kept := map[string]SelectionRule{}
+	for _, r := range others {
+		key := ruleKey(r)
+		if k, ok := kept[key]; ok {
+			redundant = append(redundant, RedundantRule{Rule: r, SubsumedBy: k})
+			continue
+		}
+		kept[key] = r
+		normalized = append(normalized, r)
+	}
+	sortRules(normalized)
+	if normalized == nil {
+		normalized = []SelectionRule{}
+	}
+
+	// Report rules subsumed by other rules.  Of two equivalent rules, only
+	// the later one is reported.
+	for i, r := range normalized {
+		if arnRule, ok := r.(ArnRule); ok {
+			redundant = append(redundant, subsumedArns(arnRule, normalized, i)...)
+			continue
+		}
+		for j, other := range normalized {
+			if i == j || !subsumes(other, r) {
+				continue
+			}
+			if j > i && subsumes(r, other) {
+				continue
+			}
+			redundant = append(redundant, RedundantRule{Rule: r, SubsumedBy: other})
+			break
+		}
+	}
+
+	return normalized, redundant
+}
+
+// subsumedArns reports the ARNs of r (at index i of rules) that other rules
+// select.
+func subsumedArns(r ArnRule, rules []SelectionRule, i int) (redundant []RedundantRule) {
+	for j, other := range rules {
+		if i == j {
+			continue
+		}
+		var covered []string
+		for _, arn := range r.Arns {
+			if subsumes(other, NewArnRule([]string{arn})) {
+				covered = append(covered, arn)
+			}
+		}
+		if len(covered) > 0 {
+			redundant = append(redundant, RedundantRule{
+				Rule:       NewArnRule(covered),
+				SubsumedBy: other,
+			})
+		}
+	}
+	return redundant
+}
+
+// repeatedValues reports the values of the list rule r that earlier rules of
+// the same type already list, grouped by the first earlier rule that lists
+// them.
+func repeatedValues(r SelectionRule, earlier []SelectionRule) (redundant []RedundantRule) {
+	covered := make([][]string, len(earlier))
+	for _, v := range sortedUnique(listValues(r)) {
+		for i, e := range earlier {
+			if e.GetRuleType() == r.GetRuleType() && SliceContainsString(v, listValues(e)) {
+				covered[i] = append(covered[i], v)
+				break
+			}
+		}
+	}
+	for i, values := range covered {
+		if len(values) > 0 {
+			redundant = append(redundant, RedundantRule{
+				Rule:       withListValues(r, values),
+				SubsumedBy: earlier[i],
+			})
+		}
+	}
+	return redundant
+}
+
+// listValues returns the values of an ArnRule, ArnPatternRule or
+// ResourceTypeRule.
+func listValues(r SelectionRule) []string {
+	switch t := r.(type) {
+	case ArnRule:
+		return t.Arns
+	case ArnPatternRule:
+		return t.Patterns
+	case ResourceTypeRule:
+		return t.ResourceTypes
+	}
+	return nil
+}
+
+// withListValues returns a rule of the same type as the list rule r with
+// the specified values.
+func withListValues(r SelectionRule, values []string) SelectionRule {
+	switch r.(type) {
+	case ArnRule:
+		return NewArnRule(values)
+	case ArnPatternRule:
+		return NewArnPatternRule(values)
+	case ResourceTypeRule:
+		return NewResourceTypeRule(values)
+	}
+	return r
+}
+
+// normalizeRule sorts and deduplicates the lists in r, recursively.
+func normalizeRule(r SelectionRule) SelectionRule {
+	switch t := r.(type) {
+	case ArnRule:
+		t.Arns = sortedUnique(t.Arns)
+		return t
+	case ArnPatternRule:
+		t.Patterns = sortedUnique(t.Patterns)
+		return t
+	case ResourceTypeRule:
+		t.ResourceTypes = sortedUnique(t.ResourceTypes)
+		return t
+	case TagMatchRule:
+		if t.Values != nil {
+			t.Values = sortedUnique(t.Values)
+		}
+		return t
+	case AllOfRule:
+		t.Rules = normalizeRuleSet(t.Rules)
+		return t
+	case AnyOfRule:
+		t.Rules = normalizeRuleSet(t.Rules)
+		return t
+	case NotRule:
+		if t.Rule != nil {
+			t.Rule = normalizeRule(t.Rule)
+		}
+		return t
+	default:
+		return r
+	}
+}
+
+// normalizeRuleSet normalizes the members of a composite rule, whose order
+// doesn't matter.
+func normalizeRuleSet(rules []SelectionRule) []SelectionRule {
+	seen := map[string]bool{}
+	normalized := []SelectionRule{}
+	for _, r := range rules {
+		r = normalizeRule(r)
+		key := ruleKey(r)
+		if !seen[key] {
+			seen[key] = true
+			normalized = append(normalized, r)
+		}
+	}
+	sortRules(normalized)
+	return normalized
+}
+
+// ruleKey returns a string that is the same for identical rules.
+func ruleKey(r SelectionRule) string {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return r.GetRuleType()
+	}
+	return string(b)
+}
+
+func sortRules(rules []SelectionRule) {
+	sort.SliceStable(rules, func(i, j int) bool {
+		return ruleKey(rules[i]) < ruleKey(rules[j])
+	})
+}
+
+func sortedUnique(s []string) []string {
+	unique := []string{}
+	for _, v := range s {
+		if !SliceContainsString(v, unique) {
+			unique = append(unique, v)
+		}
+	}
+	sort.Strings(unique)
+	return unique
+}
+
+// subsumes reports whether rule a is known to select every resource rule b
+// selects.  It errs on the side of false.
+func subsumes(a, b SelectionRule) bool {
+	if ruleKey(a) == ruleKey(b) {
+		return true
+	}
+
+	// Composite rules
+	switch tb := b.(type) {
+	case AllOfRule:
+		for _, sub := range tb.Rules {
+			if subsumes(a, sub) {
+				return true
+			}
+		}
+	case AnyOfRule:
+		if len(tb.Rules) > 0 {
+			all := true
+			for _, sub := range tb.Rules {
+				if !subsumes(a, sub) {
+					all = false
+					break
+				}
+			}
+			if all {
+				return true
+			}
+		}
+	}
+	if ta, ok := a.(AnyOfRule); ok {
+		for _, sub := range ta.Rules {
+			if subsumes(sub, b) {
+				return true
+			}
+		}
+	}
+
+	switch ta := a.(type) {
+	case ArnRule:
+		if tb, ok := b.(ArnRule); ok {
+			return isSubset(tb.Arns, ta.Arns)
+		}
+	case ArnPatternRule:
+		var candidates []string
+		switch tb := b.(type) {
+		case ArnRule:
+			candidates = tb.Arns
+		case ArnPatternRule:
+			// A pattern's wildcards can only be covered by wildcards, so
+			// matching it literally is sound
+			candidates = tb.Patterns
+		default:
+			return false
+		}
+		for _, c := range candidates {
+			if !ta.Matches(StagedResource{ARN: c}) {
+				return false
+			}
+		}
+		return true
+	case ResourceTypeRule:
+		if tb, ok := b.(ResourceTypeRule); ok {
+			return isSubset(tb.ResourceTypes, ta.ResourceTypes)
+		}
+	case TagRule:
+		if tb, ok := b.(TagMatchRule); ok && tb.Name == ta.Name && tb.Match == TagMatchOneOf {
+			return len(tb.Values) > 0 && isSubset(tb.Values, []string{ta.Value})
+		}
+	case TagMatchRule:
+		name, values, ok := tagValues(b)
+		if !ok || name != ta.Name {
+			return false
+		}
+		switch ta.Match {
+		case TagMatchExists:
+			return true
+		case TagMatchOneOf:
+			return values != nil && isSubset(values, ta.Values)
+		case TagMatchPrefix:
+			if tb, ok := b.(TagMatchRule); ok && tb.Match == TagMatchPrefix {
+				// Prefixes of b are covered if they extend a prefix of a
+				values = tb.Values
+			} else if values == nil {
+				return false
+			}
+			for _, v := range values {
+				if !hasAnyPrefix(v, ta.Values) {
+					return false
+				}
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// tagValues returns the tag name a tag rule tests and the exact values it
+// accepts, or nil values if it accepts a value pattern.
+func tagValues(r SelectionRule) (name string, values []string, ok bool) {
+	switch t := r.(type) {
+	case TagRule:
+		return t.Name, []string{t.Value}, true
+	case TagMatchRule:
+		if t.Match == TagMatchOneOf {
+			return t.Name, t.Values, true
+		}
+		return t.Name, nil, true
+	}
+	return "", nil, false
+}
+
+func isSubset(sub, super []string) bool {
+	for _, v := range sub {
+		if !SliceContainsString(v, super) {
+			return false
+		}
+	}
+	return true
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
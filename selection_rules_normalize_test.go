@@ -0,0 +1,136 @@
+package arpio
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeSelectionRules(t *testing.T) {
+	rules := []SelectionRule{
+		NewTagRule("team", "web"),
+		NewArnRule([]string{"arn:b", "arn:a"}),
+		NewTagRule("env", "prod"),
+		NewArnRule([]string{"arn:c", "arn:a"}),
+		NewTagRule("env", "prod"),
+		NewResourceTypeRule([]string{"AWS::S3::Bucket"}),
+	}
+
+	normalized, redundant := NormalizeSelectionRules(rules)
+	expected := []SelectionRule{
+		NewArnRule([]string{"arn:a", "arn:b", "arn:c"}),
+		NewResourceTypeRule([]string{"AWS::S3::Bucket"}),
+		NewTagRule("env", "prod"),
+		NewTagRule("team", "web"),
+	}
+	if !reflect.DeepEqual(normalized, expected) {
+		t.Fatalf("%#v != %#v", normalized, expected)
+	}
+
+	expectedRedundant := []RedundantRule{
+		{Rule: NewArnRule([]string{"arn:a"}), SubsumedBy: NewArnRule([]string{"arn:b", "arn:a"})},
+		{Rule: NewTagRule("env", "prod"), SubsumedBy: NewTagRule("env", "prod")},
+	}
+	if !reflect.DeepEqual(redundant, expectedRedundant) {
+		t.Fatalf("%#v != %#v", redundant, expectedRedundant)
+	}
+
+	// Normalizing is deterministic regardless of input order
+	reversed := make([]SelectionRule, len(rules))
+	for i, r := range rules {
+		reversed[len(rules)-1-i] = r
+	}
+	normalized, _ = NormalizeSelectionRules(reversed)
+	if !reflect.DeepEqual(normalized, expected) {
+		t.Fatalf("%#v != %#v", normalized, expected)
+	}
+}
+
+func TestNormalizeSelectionRulesComposite(t *testing.T) {
+	rules := []SelectionRule{
+		NewAnyOfRule([]SelectionRule{
+			NewTagOneOfRule("env", []string{"staging", "prod", "prod"}),
+			NewNotRule(NewArnPatternRule([]string{"arn:y*", "arn:x*"})),
+			NewTagOneOfRule("env", []string{"prod", "staging"}),
+		}),
+	}
+
+	normalized, redundant := NormalizeSelectionRules(rules)
+	expected := []SelectionRule{
+		NewAnyOfRule([]SelectionRule{
+			NewNotRule(NewArnPatternRule([]string{"arn:x*", "arn:y*"})),
+			NewTagOneOfRule("env", []string{"prod", "staging"}),
+		}),
+	}
+	if !reflect.DeepEqual(normalized, expected) {
+		t.Fatalf("%#v != %#v", normalized, expected)
+	}
+	if len(redundant) != 0 {
+		t.Fatalf("%#v is not empty", redundant)
+	}
+}
+
+func TestNormalizeSelectionRulesSubsumed(t *testing.T) {
+	exists := NewTagExistsRule("env")
+	patterns := NewArnPatternRule([]string{"arn:aws:s3:::logs-*"})
+	prefix := NewTagPrefixRule("team", "web")
+	cases := []struct {
+		rule       SelectionRule
+		subsumedBy SelectionRule
+	}{
+		{NewTagRule("env", "prod"), exists},
+		{NewTagOneOfRule("env", []string{"prod", "staging"}), exists},
+		{NewTagPrefixRule("env", "p"), exists},
+		{NewTagRule("team", "web-frontend"), prefix},
+		{NewTagPrefixRule("team", "web-"), prefix},
+		{NewAllOfRule([]SelectionRule{NewTagRule("env", "prod"), NewTagRule("tier", "db")}), exists},
+		{NewAnyOfRule([]SelectionRule{NewTagRule("env", "dev"), NewTagRule("env", "prod")}), exists},
+	}
+	for _, c := range cases {
+		_, redundant := NormalizeSelectionRules([]SelectionRule{c.rule, c.subsumedBy})
+		expected := []RedundantRule{{Rule: c.rule, SubsumedBy: c.subsumedBy}}
+		if !reflect.DeepEqual(redundant, expected) {
+			t.Fatalf("%#v != %#v", redundant, expected)
+		}
+	}
+
+	// ARNs matched by a pattern are reported on their own
+	_, redundant := NormalizeSelectionRules([]SelectionRule{
+		NewArnRule([]string{"arn:aws:s3:::logs-a", "arn:aws:s3:::data"}),
+		patterns,
+	})
+	expected := []RedundantRule{
+		{Rule: NewArnRule([]string{"arn:aws:s3:::logs-a"}), SubsumedBy: patterns},
+	}
+	if !reflect.DeepEqual(redundant, expected) {
+		t.Fatalf("%#v != %#v", redundant, expected)
+	}
+
+	// Rules that only overlap are not reported
+	_, redundant = NormalizeSelectionRules([]SelectionRule{
+		NewTagRule("env", "prod"),
+		NewTagPrefixRule("team", "web"),
+		NewNotRule(NewTagRule("env", "prod")),
+		NewTagPrefixRule("env", "dev"),
+	})
+	if len(redundant) != 0 {
+		t.Fatalf("%#v is not empty", redundant)
+	}
+}
+
+func TestNormalizeSelectionRulesRepeatedValues(t *testing.T) {
+	rules := []SelectionRule{
+		NewArnRule([]string{"arn:a"}),
+		NewResourceTypeRule([]string{"AWS::S3::Bucket"}),
+		NewArnRule([]string{"arn:a", "arn:b"}),
+		NewResourceTypeRule([]string{"AWS::EC2::Instance", "AWS::S3::Bucket"}),
+	}
+
+	_, redundant := NormalizeSelectionRules(rules)
+	expected := []RedundantRule{
+		{Rule: NewArnRule([]string{"arn:a"}), SubsumedBy: NewArnRule([]string{"arn:a"})},
+		{Rule: NewResourceTypeRule([]string{"AWS::S3::Bucket"}), SubsumedBy: NewResourceTypeRule([]string{"AWS::S3::Bucket"})},
+	}
+	if !reflect.DeepEqual(redundant, expected) {
+		t.Fatalf("%#v != %#v", redundant, expected)
+	}
+}
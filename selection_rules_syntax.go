@@ -0,0 +1,490 @@
+package arpio
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Selection rules can be written in a small expression language, for
+// storing them in configuration files and passing them on command lines:
+//
+//	tag:env=prod and not tag:tier=scratch or arn:arn:aws:s3:::my-bucket
+//
+// Terms:
+//
+//	arn:ARN                   ArnRule (arn:(ARN, ...) for several ARNs)
+//	arnPattern:PATTERN        ArnPatternRule (or arnPattern:(PATTERN, ...))
+//	type:TYPE                 ResourceTypeRule (or type:(TYPE, ...))
+//	tag:NAME=VALUE            TagRule
+//	tag:NAME                  TagMatchRule matching any value
+//	tag:NAME^=PREFIX          TagMatchRule matching a prefix (or ^=(PREFIX, ...))
+//	tag:NAME in (VALUE, ...)  TagMatchRule matching a set of values
+//
+// Terms combine with "not", "and" and "or" (in order of precedence) and
+// parentheses.  "and" and "not" produce AllOfRule and NotRule; "or"
+// produces separate top-level rules (which the App combines as a union),
+// or an AnyOfRule inside parentheses.  Names and values containing spaces
+// or any of ( ) , " = ^ must be double-quoted, with Go string escapes.
+
+// SelectionRuleSyntaxError describes an error in a selection rule
+// expression.
+type SelectionRuleSyntaxError struct {
+	// Offset is the byte offset of the error in the expression.
+	Offset int
+
+	// Line and Column are the 1-based position of the error.
+	Line   int
+	Column int
+
+	Msg string
+}
+
+func (e *SelectionRuleSyntaxError) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Msg)
+}
+
+// ParseSelectionRules parses a selection rule expression.  An empty
+// expression yields no rules.
+func ParseSelectionRules(s string) ([]SelectionRule, error) {
+	p := &ruleParser{s: s}
+	rules := []SelectionRule{}
+
+	p.skipSpace()
+	if p.eof() {
+		return rules, nil
+	}
+	for {
+		r, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, r)
+
+		p.skipSpace()
+		if p.eof() {
+			return rules, nil
+		}
+		if !p.acceptKeyword("or") {
+			return nil, p.errorf("expected \"and\", \"or\" or end of expression")
+		}
+	}
+}
+
+type ruleParser struct {
+	s   string
+	pos int
+}
+
+func (p *ruleParser) eof() bool {
+	return p.pos >= len(p.s)
+}
+
+func (p *ruleParser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.s[p.pos]
+}
+
+func (p *ruleParser) skipSpace() {
+	for !p.eof() && isSpace(p.s[p.pos]) {
+		p.pos++
+	}
+}
+
+func (p *ruleParser) errorf(format string, args ...interface{}) error {
+	return p.errorAt(p.pos, format, args...)
+}
+
+func (p *ruleParser) errorAt(offset int, format string, args ...interface{}) error {
+	line, column := 1, 1
+	for _, c := range p.s[:offset] {
+		if c == '\n' {
+			line++
+			column = 1
+		} else {
+			column++
+		}
+	}
+	return &SelectionRuleSyntaxError{
+		Offset: offset,
+		Line:   line,
+		Column: column,
+		Msg:    fmt.Sprintf(format, args...),
+	}
+}
+
+// word returns the identifier at the current position without consuming it.
+func (p *ruleParser) word() string {
+	end := p.pos
+	for end < len(p.s) && isIdentChar(p.s[end]) {
+		end++
+	}
+	return p.s[p.pos:end]
+}
+
+// acceptKeyword consumes the keyword if it is next.
+func (p *ruleParser) acceptKeyword(keyword string) bool {
+	p.skipSpace()
+	if p.word() != keyword {
+		return false
+	}
+	p.pos += len(keyword)
+	return true
+}
+
+func (p *ruleParser) expect(c byte) error {
+	p.skipSpace()
+	if p.peek() != c {
+		return p.errorf("expected %q", c)
+	}
+	p.pos++
+	return nil
+}
+
+// parseOr parses rules separated by "or", producing an AnyOfRule if there
+// is more than one.
+func (p *ruleParser) parseOr() (SelectionRule, error) {
+	var rules []SelectionRule
+	for {
+		r, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, r)
+		if !p.acceptKeyword("or") {
+			break
+		}
+	}
+	if len(rules) == 1 {
+		return rules[0], nil
+	}
+	return NewAnyOfRule(rules), nil
+}
+
+// parseAnd parses rules separated by "and", producing an AllOfRule if
+// there is more than one.
+func (p *ruleParser) parseAnd() (SelectionRule, error) {
+	var rules []SelectionRule
+	for {
+		r, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, r)
+		if !p.acceptKeyword("and") {
+			break
+		}
+	}
+	if len(rules) == 1 {
+		return rules[0], nil
+	}
+	return NewAllOfRule(rules), nil
+}
+
+func (p *ruleParser) parseUnary() (SelectionRule, error) {
+	if p.acceptKeyword("not") {
+		r, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return NewNotRule(r), nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *ruleParser) parsePrimary() (SelectionRule, error) {
+	p.skipSpace()
+	if p.eof() {
+		return nil, p.errorf("unexpected end of expression")
+	}
+	if p.peek() == '(' {
+		p.pos++
+		r, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(')'); err != nil {
+			return nil, err
+		}
+		return r, nil
+	}
+
+	start := p.pos
+	kind := p.word()
+	if kind == "" || p.pos+len(kind) >= len(p.s) || p.s[p.pos+len(kind)] != ':' {
+		return nil, p.errorf("expected a rule such as tag:NAME=VALUE or arn:ARN")
+	}
+	p.pos += len(kind) + 1
+
+	switch kind {
+	case "arn":
+		arns, err := p.parseValues()
+		if err != nil {
+			return nil, err
+		}
+		return NewArnRule(arns), nil
+	case "arnPattern":
+		patterns, err := p.parseValues()
+		if err != nil {
+			return nil, err
+		}
+		return NewArnPatternRule(patterns), nil
+	case "type":
+		types, err := p.parseValues()
+		if err != nil {
+			return nil, err
+		}
+		return NewResourceTypeRule(types), nil
+	case "tag":
+		return p.parseTag()
+	default:
+		return nil, p.errorAt(start, "unknown rule kind %q", kind)
+	}
+}
+
+// parseTag parses the part of a tag term after "tag:".
+func (p *ruleParser) parseTag() (SelectionRule, error) {
+	name, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case p.peek() == '=':
+		p.pos++
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		return NewTagRule(name, value), nil
+	case strings.HasPrefix(p.s[p.pos:], "^="):
+		p.pos += 2
+		prefixes, err := p.parseValues()
+		if err != nil {
+			return nil, err
+		}
+		r := NewTagPrefixRule(name, "")
+		r.Values = prefixes
+		return r, nil
+	}
+
+	// "in" must be followed by a list; otherwise it's the next term's
+	// problem (and a syntax error there)
+	save := p.pos
+	if p.acceptKeyword("in") {
+		p.skipSpace()
+		if p.peek() != '(' {
+			return nil, p.errorf("expected \"(\" after \"in\"")
+		}
+		values, err := p.parseValues()
+		if err != nil {
+			return nil, err
+		}
+		return NewTagOneOfRule(name, values), nil
+	}
+	p.pos = save
+	return NewTagExistsRule(name), nil
+}
+
+// parseValues parses a single value or a parenthesized, comma-separated
+// list of values.
+func (p *ruleParser) parseValues() ([]string, error) {
+	p.skipSpace()
+	if p.peek() != '(' {
+		v, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		return []string{v}, nil
+	}
+
+	p.pos++
+	values := []string{}
+	p.skipSpace()
+	if p.peek() == ')' {
+		p.pos++
+		return values, nil
+	}
+	for {
+		p.skipSpace()
+		v, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+
+		p.skipSpace()
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case ')':
+			p.pos++
+			return values, nil
+		default:
+			return nil, p.errorf("expected \",\" or \")\"")
+		}
+	}
+}
+
+// parseValue parses a bare or double-quoted name or value.
+func (p *ruleParser) parseValue() (string, error) {
+	start := p.pos
+	if p.peek() == '"' {
+		end := p.pos + 1
+		for end < len(p.s) && p.s[end] != '"' {
+			if p.s[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		if end >= len(p.s) {
+			return "", p.errorAt(start, "unterminated quoted string")
+		}
+		v, err := strconv.Unquote(p.s[start : end+1])
+		if err != nil {
+			return "", p.errorAt(start, "invalid quoted string: %s", err)
+		}
+		p.pos = end + 1
+		return v, nil
+	}
+
+	for !p.eof() && !isSpecialValueChar(p.peek()) {
+		p.pos++
+	}
+	if p.pos == start {
+		return "", p.errorf("expected a name or value")
+	}
+	return p.s[start:p.pos], nil
+}
+
+func isIdentChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isSpace(c byte) bool {
+	return strings.IndexByte(" \t\n\r\v\f", c) >= 0
+}
+
+func isSpecialValueChar(c byte) bool {
+	return isSpace(c) || strings.IndexByte(`(),"=^`, c) >= 0
+}
+
+// FormatSelectionRules prints rules in the expression language read by
+// ParseSelectionRules, such that parsing the result yields equivalent
+// rules.  An error is returned for rules the language can't express, such
+// as UnknownRule and empty composite rules.
+func FormatSelectionRules(rules []SelectionRule) (string, error) {
+	parts := make([]string, len(rules))
+	for i, r := range rules {
+		s, err := formatRule(r, precedenceOr)
+		if err != nil {
+			return "", err
+		}
+		// A top-level "or" would split into separate rules
+		if _, ok := r.(AnyOfRule); ok {
+			s = "(" + s + ")"
+		}
+		parts[i] = s
+	}
+	return strings.Join(parts, " or "), nil
+}
+
+// Operator precedence, lowest first.
+const (
+	precedenceOr = iota
+	precedenceAnd
+	precedenceNot
+)
+
+// formatRule prints a rule, parenthesizing it if it binds more loosely than
+// its context requires.
+func formatRule(r SelectionRule, context int) (string, error) {
+	switch t := r.(type) {
+	case ArnRule:
+		return "arn:" + formatValues(t.Arns), nil
+	case ArnPatternRule:
+		return "arnPattern:" + formatValues(t.Patterns), nil
+	case ResourceTypeRule:
+		return "type:" + formatValues(t.ResourceTypes), nil
+	case TagRule:
+		return "tag:" + formatValue(t.Name) + "=" + formatValue(t.Value), nil
+	case TagMatchRule:
+		switch t.Match {
+		case TagMatchExists:
+			return "tag:" + formatValue(t.Name), nil
+		case TagMatchPrefix:
+			return "tag:" + formatValue(t.Name) + "^=" + formatValues(t.Values), nil
+		case TagMatchOneOf:
+			return "tag:" + formatValue(t.Name) + " in " + formatList(t.Values), nil
+		default:
+			return "", fmt.Errorf("cannot format tag match mode %q", t.Match)
+		}
+	case AllOfRule:
+		// Nested "and" is parenthesized to keep its structure
+		return formatComposite(t.Rules, " and ", precedenceAnd+1, precedenceAnd, context, AllOfRuleType)
+	case AnyOfRule:
+		return formatComposite(t.Rules, " or ", precedenceOr+1, precedenceOr, context, AnyOfRuleType)
+	case NotRule:
+		if t.Rule == nil {
+			return "", fmt.Errorf("cannot format a %s rule without a rule", NotRuleType)
+		}
+		s, err := formatRule(t.Rule, precedenceNot)
+		if err != nil {
+			return "", err
+		}
+		return "not " + s, nil
+	default:
+		return "", fmt.Errorf("cannot format selection rule type %q", r.GetRuleType())
+	}
+}
+
+func formatComposite(rules []SelectionRule, sep string, childContext, precedence, context int, ruleType string) (string, error) {
+	if len(rules) < 2 {
+		return "", fmt.Errorf("cannot format a %s rule with fewer than two rules", ruleType)
+	}
+	parts := make([]string, len(rules))
+	for i, r := range rules {
+		s, err := formatRule(r, childContext)
+		if err != nil {
+			return "", err
+		}
+		parts[i] = s
+	}
+	s := strings.Join(parts, sep)
+	if precedence < context {
+		s = "(" + s + ")"
+	}
+	return s, nil
+}
+
+// formatValues prints a single value bare (or quoted), and other numbers of
+// values as a list.
+func formatValues(values []string) string {
+	if len(values) == 1 {
+		return formatValue(values[0])
+	}
+	return formatList(values)
+}
+
+func formatList(values []string) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = formatValue(v)
+	}
+	return "(" + strings.Join(parts, ", ") + ")"
+}
+
+// formatValue quotes v if it can't be written bare.
+func formatValue(v string) string {
+	if v == "" {
+		return `""`
+	}
+	for i := 0; i < len(v); i++ {
+		if isSpecialValueChar(v[i]) || v[i] >= 0x80 {
+			return strconv.Quote(v)
+		}
+	}
+	return v
+}
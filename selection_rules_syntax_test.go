@@ -0,0 +1,114 @@
+package arpio
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseSelectionRules(t *testing.T) {
+	rules, err := ParseSelectionRules(`tag:env=prod and not tag:tier=scratch or arn:arn:aws:rds:us-east-1:111111111111:cluster:db`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []SelectionRule{
+		NewAllOfRule([]SelectionRule{
+			NewTagRule("env", "prod"),
+			NewNotRule(NewTagRule("tier", "scratch")),
+		}),
+		NewArnRule([]string{"arn:aws:rds:us-east-1:111111111111:cluster:db"}),
+	}
+	if !reflect.DeepEqual(rules, expected) {
+		t.Fatalf("%#v != %#v", rules, expected)
+	}
+}
+
+func TestParseSelectionRulesTerms(t *testing.T) {
+	cases := map[string]SelectionRule{
+		`arn:(arn:a, arn:b)`:             NewArnRule([]string{"arn:a", "arn:b"}),
+		`arnPattern:arn:aws:s3:::logs-*`: NewArnPatternRule([]string{"arn:aws:s3:::logs-*"}),
+		`type:AWS::RDS::DBCluster`:       NewResourceTypeRule([]string{"AWS::RDS::DBCluster"}),
+		`tag:backup`:                     NewTagExistsRule("backup"),
+		`tag:team^=data-`:                NewTagPrefixRule("team", "data-"),
+		`tag:env in (prod, staging)`:     NewTagOneOfRule("env", []string{"prod", "staging"}),
+		`tag:"cost center"=""`:           NewTagRule("cost center", ""),
+		`(tag:a or tag:b) and tag:c`: NewAllOfRule([]SelectionRule{
+			NewAnyOfRule([]SelectionRule{NewTagExistsRule("a"), NewTagExistsRule("b")}),
+			NewTagExistsRule("c"),
+		}),
+	}
+	for in, expected := range cases {
+		rules, err := ParseSelectionRules(in)
+		if err != nil {
+			t.Errorf("%s: %s", in, err)
+			continue
+		}
+		if len(rules) != 1 || !reflect.DeepEqual(rules[0], expected) {
+			t.Errorf("%s: %#v != %#v", in, rules, expected)
+		}
+	}
+}
+
+func TestParseSelectionRulesErrors(t *testing.T) {
+	cases := map[string]int{
+		`tag:env=prod and`:       17,
+		`tag:env=prod tag:a`:     14,
+		`bogus:x`:                1,
+		`(tag:a or tag:b`:        16,
+		`tag:"unterminated`:      5,
+		`arn:(arn:a arn:b)`:      12,
+		`tag:env=prod or or tag`: 17,
+	}
+	for in, column := range cases {
+		_, err := ParseSelectionRules(in)
+		var syntaxErr *SelectionRuleSyntaxError
+		if !errors.As(err, &syntaxErr) {
+			t.Errorf("%s: expected a syntax error, got %v", in, err)
+			continue
+		}
+		if syntaxErr.Line != 1 || syntaxErr.Column != column {
+			t.Errorf("%s: error at %d:%d, not 1:%d (%s)", in, syntaxErr.Line, syntaxErr.Column, column, syntaxErr)
+		}
+	}
+}
+
+func TestFormatSelectionRulesRoundTrip(t *testing.T) {
+	rules := []SelectionRule{
+		NewAllOfRule([]SelectionRule{
+			NewTagRule("env", "prod"),
+			NewNotRule(NewAnyOfRule([]SelectionRule{
+				NewTagRule("tier", "scratch"),
+				NewTagPrefixRule("name", "tmp-"),
+			})),
+			NewAllOfRule([]SelectionRule{NewTagExistsRule("a"), NewTagExistsRule("b")}),
+		}),
+		NewAnyOfRule([]SelectionRule{NewArnRule([]string{}), NewResourceTypeRule([]string{"AWS::S3::Bucket"})}),
+		NewTagOneOfRule("owner", []string{"a=b", "c d", "ü"}),
+		NewArnRule([]string{"arn:aws:iam::111111111111:role/a,b"}),
+	}
+
+	s, err := FormatSelectionRules(rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `tag:env=prod and not (tag:tier=scratch or tag:name^=tmp-) and (tag:a and tag:b) or (arn:() or type:AWS::S3::Bucket) or tag:owner in ("a=b", "c d", "ü") or arn:"arn:aws:iam::111111111111:role/a,b"`
+	if s != expected {
+		t.Fatalf("%s != %s", s, expected)
+	}
+
+	parsed, err := ParseSelectionRules(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(parsed, rules) {
+		t.Fatalf("%#v != %#v", parsed, rules)
+	}
+}
+
+func TestFormatSelectionRulesUnknown(t *testing.T) {
+	_, err := FormatSelectionRules([]SelectionRule{UnknownRule{selectionRule: selectionRule{RuleType: "future"}}})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+}
@@ -2,8 +2,12 @@ package arpio
 
 import (
 	"encoding/json"
+	"errors"
 	"reflect"
+	"strings"
 	"testing"
+
+	"github.com/arpio/arpio-client-go/arn"
 )
 
 func TestArnRuleJSON(t *testing.T) {
@@ -62,3 +66,77 @@ func TestTagRuleJSON(t *testing.T) {
 		t.Fatalf("%v != %v", orig, decoded)
 	}
 }
+
+func TestPatternAndTypeRulesJSON(t *testing.T) {
+	orig := App{
+		SelectionRules: []SelectionRule{
+			NewArnPatternRule([]string{"arn:aws:s3:::logs-*"}),
+			NewResourceTypeRule([]string{"AWS::RDS::DBCluster"}),
+			NewTagExistsRule("backup"),
+			NewTagPrefixRule("team", "data-"),
+			NewTagOneOfRule("env", []string{"prod", "staging"}),
+		},
+	}
+	bytes, err := json.Marshal(orig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `[{"ruleType":"arnPattern","patterns":["arn:aws:s3:::logs-*"]},{"ruleType":"resourceType","resourceTypes":["AWS::RDS::DBCluster"]},{"ruleType":"tagMatch","name":"backup","match":"exists"},{"ruleType":"tagMatch","name":"team","match":"prefix","values":["data-"]},{"ruleType":"tagMatch","name":"env","match":"oneOf","values":["prod","staging"]}]`
+	if !strings.HasSuffix(string(bytes), `"selectionRules":`+expected+`}`) {
+		t.Fatalf("%s does not end with %s", bytes, expected)
+	}
+
+	var decoded App
+	err = json.Unmarshal(bytes, &decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(orig.SelectionRules, decoded.SelectionRules) {
+		t.Fatalf("%#v != %#v", orig.SelectionRules, decoded.SelectionRules)
+	}
+}
+
+func TestPatternAndTypeRulesMatch(t *testing.T) {
+	bucket := StagedResource{
+		ARN:  "arn:aws:s3:::logs-2021",
+		Type: "AWS::S3::Bucket",
+		Tags: map[string]string{"team": "data-eng", "env": "prod"},
+	}
+	cases := []struct {
+		rule  ResourceMatcher
+		match bool
+	}{
+		{NewArnPatternRule([]string{"arn:aws:s3:::logs-*"}), true},
+		{NewArnPatternRule([]string{"arn:aws:s3:::data-*", "arn:aws:rds:*"}), false},
+		{NewResourceTypeRule([]string{"AWS::S3::Bucket"}), true},
+		{NewResourceTypeRule([]string{"AWS::RDS::DBCluster"}), false},
+		{NewTagExistsRule("team"), true},
+		{NewTagExistsRule("owner"), false},
+		{NewTagPrefixRule("team", "data-"), true},
+		{NewTagPrefixRule("team", "web-"), false},
+		{NewTagOneOfRule("env", []string{"prod", "staging"}), true},
+		{NewTagOneOfRule("env", []string{"dev"}), false},
+	}
+	for i, tc := range cases {
+		if tc.rule.Matches(bucket) != tc.match {
+			t.Errorf("case %d: %#v expected match=%v", i, tc.rule, tc.match)
+		}
+	}
+}
+
+func TestArnRuleValidate(t *testing.T) {
+	valid := NewArnRule([]string{"arn:aws:s3:::bucket", "arn:aws:rds:us-east-1:123456789012:db:db1"})
+	if err := valid.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	invalid := NewArnRule([]string{"arn:aws:s3:::bucket", "arn:aws:rds:us-east-1:1234:db:db1"})
+	var arnErr *arn.Error
+	if err := invalid.Validate(); !errors.As(err, &arnErr) {
+		t.Fatalf("expected *arn.Error, got %v", err)
+	}
+	if arnErr.ARN != invalid.Arns[1] {
+		t.Fatalf("%s != %s", arnErr.ARN, invalid.Arns[1])
+	}
+}
@@ -0,0 +1,65 @@
+package arpio
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSelectResources(t *testing.T) {
+	resources := []StagedResource{
+		{ARN: "arn:a", Tags: map[string]string{"env": "prod"}},
+		{ARN: "arn:b", Tags: map[string]string{"env": "dev"}},
+		{ARN: "arn:c"},
+		{ARN: "arn:d", Tags: map[string]string{"env": "prod"}},
+	}
+	arnRule := NewArnRule([]string{"arn:a", "arn:c"})
+	tagRule := NewTagRule("env", "prod")
+	app := App{SelectionRules: []SelectionRule{arnRule, tagRule}}
+
+	selected, err := app.SelectResources(resources)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]int{"arn:a": 2, "arn:c": 1, "arn:d": 1}
+	if len(selected) != len(expected) {
+		t.Fatalf("%d resources selected != %d", len(selected), len(expected))
+	}
+	for _, s := range selected {
+		if len(s.Rules) != expected[s.Resource.ARN] {
+			t.Fatalf("%s matched %d rules, not %d", s.Resource.ARN, len(s.Rules), expected[s.Resource.ARN])
+		}
+	}
+	if _, ok := selected[2].Rules[0].(TagRule); !ok {
+		t.Fatalf("arn:d should be matched by the tag rule")
+	}
+}
+
+func TestSelectResourcesUnevaluable(t *testing.T) {
+	app := App{SelectionRules: []SelectionRule{
+		NewArnRule([]string{"arn:a"}),
+		UnknownRule{selectionRule: selectionRule{RuleType: "future"}},
+	}}
+
+	selected, err := app.SelectResources([]StagedResource{{ARN: "arn:a"}})
+	var unevaluable *UnevaluableRulesError
+	if !errors.As(err, &unevaluable) || unevaluable.RuleTypes[0] != "future" {
+		t.Fatalf("expected UnevaluableRulesError, got %v", err)
+	}
+	if len(selected) != 1 {
+		t.Fatalf("partial selection was not returned")
+	}
+}
+
+func TestTagRuleMatchesEmptyValue(t *testing.T) {
+	r := NewTagRule("backup", "")
+	if !r.Matches(StagedResource{Tags: map[string]string{"backup": ""}}) {
+		t.Fatalf("should match a tag with an empty value")
+	}
+	if r.Matches(StagedResource{Tags: map[string]string{"backup": "yes"}}) {
+		t.Fatalf("should not match a tag with a different value")
+	}
+	if r.Matches(StagedResource{}) {
+		t.Fatalf("should not match an untagged resource")
+	}
+}
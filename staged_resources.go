@@ -2,7 +2,8 @@ package arpio
 
 import (
 	"encoding/json"
-	"log"
+
+	"github.com/arpio/arpio-client-go/arn"
 )
 
 const (
@@ -85,6 +86,28 @@ type RDSOptionGroupExtra struct {
 	OptionGroupARN string `json:"optionGroupArn"`
 }
 
+// UnknownExtra holds a staged extra with a type this client doesn't
+// recognize, which usually means the Arpio service is newer than the
+// client.  The extra's JSON is preserved so it survives re-marshaling.
+type UnknownExtra struct {
+	stagedExtra
+	Raw json.RawMessage
+}
+
+// MarshalJSON returns the extra's original JSON.
+func (e UnknownExtra) MarshalJSON() ([]byte, error) {
+	if len(e.Raw) == 0 {
+		return json.Marshal(e.stagedExtra)
+	}
+	return e.Raw, nil
+}
+
+// Fields decodes the extra's JSON into a generic map for inspection.
+func (e UnknownExtra) Fields() (fields map[string]interface{}, err error) {
+	err = json.Unmarshal(e.Raw, &fields)
+	return fields, err
+}
+
 type StagedResource struct {
 	ARN    string            `json:"arn"`
 	Tags   map[string]string `json:"tags"`
@@ -132,41 +155,13 @@ func (sr *StagedResource) UnmarshalJSON(b []byte) error {
 		}
 
 		var extra StagedExtra
-		switch baseExtra.Type {
-		case BackupRecoveryPointExtraType:
-			e := &BackupRecoveryPointExtra{}
-			err = json.Unmarshal(rawExtra, e)
-			extra = *e
-		case BackupVaultExtraType:
-			e := &BackupVaultExtra{}
-			err = json.Unmarshal(rawExtra, e)
-			extra = *e
-		case EC2ImageExtraType:
-			e := &EC2ImageExtra{}
-			err = json.Unmarshal(rawExtra, e)
-			extra = *e
-		case EC2SnapshotExtraType:
-			e := &EC2SnapshotExtra{}
-			err = json.Unmarshal(rawExtra, e)
-			extra = *e
-		case KMSKeyExtraType:
-			e := &KMSKeyExtra{}
-			err = json.Unmarshal(rawExtra, e)
-			extra = *e
-		case RDSDBClusterSnapshotExtraType:
-			e := &RDSDBClusterSnapshotExtra{}
-			err = json.Unmarshal(rawExtra, e)
-			extra = *e
-		case RDSDBSnapshotExtraType:
-			e := &RDSDBSnapshotExtra{}
-			err = json.Unmarshal(rawExtra, e)
-			extra = *e
-		case RDSOptionGroupExtraType:
-			e := &RDSOptionGroupExtra{}
-			err = json.Unmarshal(rawExtra, e)
-			extra = *e
-		default:
-			log.Printf("[DEBUG] Ignoring extra with unknown type: %s", baseExtra.Type)
+		if decode, ok := lookupStagedExtraDecoder(baseExtra.Type); ok {
+			extra, err = decode(rawExtra)
+		} else {
+			extra = UnknownExtra{
+				stagedExtra: baseExtra,
+				Raw:         append(json.RawMessage(nil), rawExtra...),
+			}
 		}
 		if err != nil {
 			return err
@@ -176,3 +171,43 @@ func (sr *StagedResource) UnmarshalJSON(b []byte) error {
 
 	return nil
 }
+
+// UnknownExtraTypes returns the types of the resource's extras that this
+// client doesn't recognize (see UnknownExtra), without duplicates.
+func (sr StagedResource) UnknownExtraTypes() (types []string) {
+	for _, e := range sr.Extras {
+		if u, ok := e.(UnknownExtra); ok && !SliceContainsString(u.Type, types) {
+			types = append(types, u.Type)
+		}
+	}
+	return types
+}
+
+// ParseARN parses the resource's ARN.
+func (sr StagedResource) ParseARN() (arn.ARN, error) {
+	return arn.Parse(sr.ARN)
+}
+
+// ServiceRegion identifies an AWS service in a region.  Region is empty for
+// global resources.
+type ServiceRegion struct {
+	Service string
+	Region  string
+}
+
+// GroupResourcesByServiceRegion groups resources by the service and region
+// in their ARNs, preserving their order within each group.  Resources whose
+// ARNs can't be parsed are returned in invalid.
+func GroupResourcesByServiceRegion(resources []StagedResource) (groups map[ServiceRegion][]StagedResource, invalid []StagedResource) {
+	groups = map[ServiceRegion][]StagedResource{}
+	for _, r := range resources {
+		a, err := r.ParseARN()
+		if err != nil {
+			invalid = append(invalid, r)
+			continue
+		}
+		key := ServiceRegion{Service: a.Service, Region: a.Region}
+		groups[key] = append(groups[key], r)
+	}
+	return groups, invalid
+}
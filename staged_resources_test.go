@@ -0,0 +1,99 @@
+package arpio
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestStagedResourceJSON(t *testing.T) {
+	in := `{"arn":"arn:a","tags":{"env":"prod"},"type":"AWS::EC2::Instance","extras":[{"type":"ec2Image","environment":"target","imageArn":"arn:image","snapshotArns":["arn:snap"]},{"type":"kmsKey","environment":"source","kmsKeyArn":"arn:key"}]}`
+
+	var decoded StagedResource
+	err := json.Unmarshal([]byte(in), &decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []StagedExtra{
+		EC2ImageExtra{
+			stagedExtra:  stagedExtra{Type: EC2ImageExtraType, Environment: TargetEnvironment},
+			ImageARN:     "arn:image",
+			SnapshotARNs: []string{"arn:snap"},
+		},
+		KMSKeyExtra{
+			stagedExtra: stagedExtra{Type: KMSKeyExtraType, Environment: SourceEnvironment},
+			KMSKeyARN:   "arn:key",
+		},
+	}
+	if !reflect.DeepEqual(decoded.Extras, expected) {
+		t.Fatalf("%#v != %#v", decoded.Extras, expected)
+	}
+
+	bytes, err := json.Marshal(decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(bytes) != in {
+		t.Fatalf("%s != %s", bytes, in)
+	}
+}
+
+func TestStagedResourceJSONUnknownExtra(t *testing.T) {
+	in := `{"arn":"arn:a","tags":null,"type":"t","extras":[{"type":"future","environment":"source","thing":{"a":1}}]}`
+
+	var decoded StagedResource
+	err := json.Unmarshal([]byte(in), &decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(decoded.Extras) != 1 {
+		t.Fatalf("%d extras != 1", len(decoded.Extras))
+	}
+	extra := decoded.Extras[0]
+	if extra.GetType() != "future" || extra.GetEnvironment() != SourceEnvironment {
+		t.Fatalf("unexpected extra %#v", extra)
+	}
+	if !reflect.DeepEqual(decoded.UnknownExtraTypes(), []string{"future"}) {
+		t.Fatalf("unexpected unknown types %v", decoded.UnknownExtraTypes())
+	}
+
+	fields, err := extra.(UnknownExtra).Fields()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := fields["thing"]; !ok {
+		t.Fatalf("unexpected fields %v", fields)
+	}
+
+	bytes, err := json.Marshal(decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(bytes) != in {
+		t.Fatalf("%s != %s", bytes, in)
+	}
+}
+
+func TestGroupResourcesByServiceRegion(t *testing.T) {
+	db := StagedResource{ARN: "arn:aws:rds:us-east-1:123456789012:db:db1"}
+	instance1 := StagedResource{ARN: "arn:aws:ec2:us-east-1:123456789012:instance/i-1"}
+	instance2 := StagedResource{ARN: "arn:aws:ec2:us-west-2:123456789012:instance/i-2"}
+	bucket := StagedResource{ARN: "arn:aws:s3:::bucket"}
+	bad := StagedResource{ARN: "arn:a"}
+
+	groups, invalid := GroupResourcesByServiceRegion([]StagedResource{db, instance1, bad, instance2, bucket})
+	expected := map[ServiceRegion][]StagedResource{
+		{Service: "rds", Region: "us-east-1"}: {db},
+		{Service: "ec2", Region: "us-east-1"}: {instance1},
+		{Service: "ec2", Region: "us-west-2"}: {instance2},
+		{Service: "s3"}:                       {bucket},
+	}
+	if !reflect.DeepEqual(groups, expected) {
+		t.Fatalf("%v != %v", groups, expected)
+	}
+	if !reflect.DeepEqual(invalid, []StagedResource{bad}) {
+		t.Fatalf("%v != %v", invalid, []StagedResource{bad})
+	}
+}
@@ -0,0 +1,142 @@
+package arpio
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SyncPhase is the stage an App's synchronization has reached.
+type SyncPhase string
+
+// Enumeration of the known sync phases.  Newer versions of the Arpio
+// service may report others.
+const (
+	SyncPhaseInitializing SyncPhase = "initializing"
+	SyncPhaseDiscovering  SyncPhase = "discovering"
+	SyncPhaseSyncing      SyncPhase = "syncing"
+	SyncPhaseSynced       SyncPhase = "synced"
+	SyncPhasePaused       SyncPhase = "paused"
+	SyncPhaseError        SyncPhase = "error"
+)
+
+// ErrAppNotFound is wrapped by the error of SyncPhaseWaiter.Wait when the
+// app stops existing.
+var ErrAppNotFound = errors.New("app not found")
+
+// SyncPhaseChange records when a SyncPhaseWaiter saw an app's sync phase.
+type SyncPhaseChange struct {
+	Phase SyncPhase
+	At    time.Time
+}
+
+// SyncPhaseWaitError is returned by SyncPhaseWaiter.Wait when the app
+// doesn't reach one of the wanted phases.  It wraps the cause, such as
+// context.DeadlineExceeded or ErrAppNotFound.
+type SyncPhaseWaitError struct {
+	AppID   string
+	Phases  []SyncPhase
+	History []SyncPhaseChange
+	Err     error
+}
+
+func (e *SyncPhaseWaitError) Error() string {
+	wanted := make([]string, len(e.Phases))
+	for i, p := range e.Phases {
+		wanted[i] = fmt.Sprintf("%q", p)
+	}
+	history := make([]string, len(e.History))
+	for i, c := range e.History {
+		history[i] = fmt.Sprintf("%q at %s", c.Phase, c.At.Format(time.RFC3339))
+	}
+	if len(history) == 0 {
+		history = []string{"none"}
+	}
+	return fmt.Sprintf("app %s did not reach sync phase %s: %s; phase history: %s",
+		e.AppID, strings.Join(wanted, " or "), e.Err, strings.Join(history, " -> "))
+}
+
+func (e *SyncPhaseWaitError) Unwrap() error {
+	return e.Err
+}
+
+// SyncPhaseWaiter waits for apps to reach sync phases.
+type SyncPhaseWaiter struct {
+	Client *Client
+
+	// PollPeriod is the time between polls of the app.  If zero,
+	// AppPollPeriod is used.
+	PollPeriod time.Duration
+
+	// Timeout limits the time to wait, in addition to the context's
+	// deadline.  If zero, there is no additional limit.
+	Timeout time.Duration
+
+	// Progress, if set, is called with the app after every poll.
+	Progress func(app App)
+}
+
+// Wait polls the app with GetApp until its sync phase is one of phases, and
+// returns the app.  If the context is done, the timeout elapses, the app
+// stops existing, or the app can't be fetched, a *SyncPhaseWaitError with
+// the phases seen so far is returned.
+func (w SyncPhaseWaiter) Wait(ctx context.Context, appID string, phases ...SyncPhase) (*App, error) {
+	if len(phases) == 0 {
+		return nil, fmt.Errorf("at least one sync phase is required")
+	}
+	pollPeriod := w.PollPeriod
+	if pollPeriod == 0 {
+		pollPeriod = AppPollPeriod
+	}
+	if w.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, w.Timeout)
+		defer cancel()
+	}
+
+	waitErr := &SyncPhaseWaitError{AppID: appID, Phases: phases}
+	for {
+		app, err := w.Client.GetAppWithContext(ctx, appID)
+		if err == nil && app == nil {
+			err = ErrAppNotFound
+		}
+		if err != nil {
+			// Report cancellation rather than the request error it caused
+			if ctx.Err() != nil {
+				err = ctx.Err()
+			}
+			waitErr.Err = err
+			return nil, waitErr
+		}
+
+		history := waitErr.History
+		if len(history) == 0 || history[len(history)-1].Phase != app.SyncPhase {
+			waitErr.History = append(history, SyncPhaseChange{Phase: app.SyncPhase, At: time.Now()})
+		}
+		if w.Progress != nil {
+			w.Progress(*app)
+		}
+		for _, p := range phases {
+			if app.SyncPhase == p {
+				return app, nil
+			}
+		}
+
+		w.Client.log(LevelDebug, "Waiting for app sync phase",
+			"appId", appID,
+			"syncPhase", string(app.SyncPhase))
+		if err := sleepContext(ctx, pollPeriod); err != nil {
+			waitErr.Err = err
+			return nil, waitErr
+		}
+	}
+}
+
+// WaitForAppSyncPhase waits until the sync phase of the app with the
+// specified ID is one of phases, polling every AppPollPeriod until ctx is
+// done.  Use a SyncPhaseWaiter to configure the polling.
+func (c *Client) WaitForAppSyncPhase(ctx context.Context, appID string, phases ...SyncPhase) (*App, error) {
+	return SyncPhaseWaiter{Client: c}.Wait(ctx, appID, phases...)
+}
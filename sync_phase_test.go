@@ -0,0 +1,102 @@
+package arpio_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	arpio "github.com/arpio/arpio-client-go"
+	"github.com/arpio/arpio-client-go/arpiotest"
+)
+
+func TestWaitForAppSyncPhase(t *testing.T) {
+	s := arpiotest.NewServer()
+	defer s.Close()
+	c, err := s.Client("acct")
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := desiredApp("web", 60)
+	a.AccountID = "acct"
+	a = s.SeedApp(a)
+
+	// Advance one phase per poll
+	next := map[arpio.SyncPhase]arpio.SyncPhase{
+		arpio.SyncPhaseInitializing: arpio.SyncPhaseDiscovering,
+		arpio.SyncPhaseDiscovering:  arpio.SyncPhaseSyncing,
+		arpio.SyncPhaseSyncing:      arpio.SyncPhaseSynced,
+	}
+	var seen []arpio.SyncPhase
+	w := arpio.SyncPhaseWaiter{
+		Client:     c,
+		PollPeriod: time.Millisecond,
+		Progress: func(app arpio.App) {
+			seen = append(seen, app.SyncPhase)
+			s.SetAppSyncPhase(app.AppID, next[app.SyncPhase])
+		},
+	}
+
+	app, err := w.Wait(context.Background(), a.AppID, arpio.SyncPhaseSynced, arpio.SyncPhaseError)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if app.SyncPhase != arpio.SyncPhaseSynced {
+		t.Fatalf("%s != %s", app.SyncPhase, arpio.SyncPhaseSynced)
+	}
+	expected := []arpio.SyncPhase{
+		arpio.SyncPhaseInitializing,
+		arpio.SyncPhaseDiscovering,
+		arpio.SyncPhaseSyncing,
+		arpio.SyncPhaseSynced,
+	}
+	if !reflect.DeepEqual(seen, expected) {
+		t.Fatalf("%v != %v", seen, expected)
+	}
+}
+
+func TestWaitForAppSyncPhaseTimeout(t *testing.T) {
+	s := arpiotest.NewServer()
+	defer s.Close()
+	c, err := s.Client("acct")
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := desiredApp("web", 60)
+	a.AccountID = "acct"
+	a.SyncPhase = arpio.SyncPhaseSyncing
+	a = s.SeedApp(a)
+
+	w := arpio.SyncPhaseWaiter{Client: c, PollPeriod: time.Millisecond, Timeout: 50 * time.Millisecond}
+	_, err = w.Wait(context.Background(), a.AppID, arpio.SyncPhaseSynced)
+	var waitErr *arpio.SyncPhaseWaitError
+	if !errors.As(err, &waitErr) {
+		t.Fatalf("expected *SyncPhaseWaitError, got %v", err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", waitErr.Err)
+	}
+	if len(waitErr.History) != 1 || waitErr.History[0].Phase != arpio.SyncPhaseSyncing {
+		t.Fatalf("unexpected history: %v", waitErr.History)
+	}
+	if !strings.Contains(err.Error(), `did not reach sync phase "synced"`) ||
+		!strings.Contains(err.Error(), `phase history: "syncing" at `) {
+		t.Fatalf("unexpected error message: %s", err)
+	}
+}
+
+func TestWaitForAppSyncPhaseNotFound(t *testing.T) {
+	s := arpiotest.NewServer()
+	defer s.Close()
+	c, err := s.Client("acct")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = c.WaitForAppSyncPhase(context.Background(), "app-missing", arpio.SyncPhaseSynced)
+	if !errors.Is(err, arpio.ErrAppNotFound) {
+		t.Fatalf("expected ErrAppNotFound, got %v", err)
+	}
+}
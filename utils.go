@@ -1,5 +1,11 @@
 package arpio
 
+import (
+	"context"
+	"strings"
+	"time"
+)
+
 // SliceContainsString checks if the string v is in the slice of strings s.
 func SliceContainsString(v string, s []string) bool {
 	for _, elem := range s {
@@ -19,3 +25,39 @@ func SliceContainsInt(v int, s []int) bool {
 	}
 	return false
 }
+
+// sleepContext pauses for the duration d, returning early with ctx's error
+// if ctx is done first.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// wildcardMatch reports whether s matches pattern, in which "*" matches any
+// sequence of characters (including none) and every other character
+// matches itself.
+func wildcardMatch(pattern, s string) bool {
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == s
+	}
+	if !strings.HasPrefix(s, parts[0]) {
+		return false
+	}
+	s = s[len(parts[0]):]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(s, part)
+		if i < 0 {
+			return false
+		}
+		s = s[i+len(part):]
+	}
+	return strings.HasSuffix(s, parts[len(parts)-1])
+}